@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox"
+)
+
+// dapMain runs a debug adapter on standard input and output. It returns the
+// exit status.
+func dapMain(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: ilox dap\n\nSpeaks the Debug Adapter Protocol on standard input and output. Launch requests name the script to debug with \"program\".\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if err := lox.ServeDAP(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
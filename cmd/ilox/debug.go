@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+)
+
+// debugMain runs a script under the console debugger. It returns the exit
+// status.
+func debugMain(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: ilox debug script\n\nRuns the script paused before its first statement. Type help at the prompt for commands.\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	err := lox.DebugFile(flags.Arg(0), interpret.Options{}, os.Stdin, os.Stdout)
+	return exitStatus(err)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+}
+
+// diff returns a unified diff from a to b, or "" if they are the same.
+func diff(name string, a, b []byte) string {
+	edits := lineEdits(splitLines(string(a)), splitLines(string(b)))
+
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change, and the end of the changes near it.
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		last, gap := first, 0
+		for j := first; j < len(edits) && gap <= 2*contextLines; j++ {
+			if edits[j].op == ' ' {
+				gap++
+			} else {
+				last, gap = j, 0
+			}
+		}
+
+		from := first - contextLines
+		if from < start {
+			from = start
+		}
+		to := last + contextLines + 1
+		if to > len(edits) {
+			to = len(edits)
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+		}
+		out.WriteString(hunkHeader(edits, from, to))
+		for _, e := range edits[from:to] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// hunkHeader describes edits[from:to] by their lines in a and b.
+func hunkHeader(edits []edit, from, to int) string {
+	aStart, bStart := 1, 1
+	for _, e := range edits[:from] {
+		if e.op != '+' {
+			aStart++
+		}
+		if e.op != '-' {
+			bStart++
+		}
+	}
+	aLen, bLen := 0, 0
+	for _, e := range edits[from:to] {
+		if e.op != '+' {
+			aLen++
+		}
+		if e.op != '-' {
+			bLen++
+		}
+	}
+	// An empty range names the line before it.
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+}
+
+// splitLines splits s into lines that each end in a newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// lineEdits finds the shortest edit from a to b through their longest common
+// subsequence of lines.
+func lineEdits(a, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = lcs[i+1][j]
+				if lcs[i][j+1] > lcs[i][j] {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{op: ' ', line: a[i]})
+			i, j = i+1, j+1
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{op: '-', line: a[i]})
+			i++
+		default:
+			edits = append(edits, edit{op: '+', line: b[j]})
+			j++
+		}
+	}
+	return edits
+}
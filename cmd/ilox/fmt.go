@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/format"
+)
+
+// fmtMain formats Lox files like gofmt. With no files it formats standard
+// input to standard output. It returns the exit status, the highest of those
+// for each file.
+func fmtMain(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: ilox fmt [-w] [-d] [files...]\n")
+		flags.PrintDefaults()
+	}
+	write := flags.Bool("w", false, "write the result to each file instead of standard output")
+	showDiff := flags.Bool("d", false, "print diffs instead of the formatted source")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintf(os.Stderr, "cannot use -w with standard input\n")
+			return exitUsage
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitNoInput
+		}
+		return formatFile("<standard input>", src, false, *showDiff)
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			status = exitNoInput
+			continue
+		}
+		if s := formatFile(path, src, *write, *showDiff); s > status {
+			status = s
+		}
+	}
+	return status
+}
+
+func formatFile(path string, src []byte, write, showDiff bool) int {
+	out, err := format.Source(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return exitDataErr
+	}
+
+	if showDiff {
+		fmt.Print(diff(path, src, out))
+	}
+	if write && !bytes.Equal(src, out) {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitIOErr
+		}
+		if err := ioutil.WriteFile(path, out, info.Mode().Perm()); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitIOErr
+		}
+	}
+	if !write && !showDiff {
+		os.Stdout.Write(out)
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/lsp"
+)
+
+// lspMain runs a language server on standard input and output. It returns the
+// exit status, which the protocol says is 1 if the client exits without
+// shutting the server down.
+func lspMain(args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: ilox lsp\n\nSpeaks the Language Server Protocol on standard input and output.\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if err := lsp.NewServer().Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
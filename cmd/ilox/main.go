@@ -1,26 +1,170 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 
 	"github.com/spencer-p/craftinginterpreters/pkg/lox"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
 )
 
+// Exit statuses, following sysexits.h as the book does.
+const (
+	exitUsage    = 64 // bad flags or arguments
+	exitDataErr  = 65 // a script has syntax errors
+	exitNoInput  = 66 // a script cannot be read
+	exitSoftware = 70 // the script failed at runtime
+	exitIOErr    = 74 // the prompt failed, or a file could not be written
+)
+
+// tokensFlag is the -tokens flag. Alone it asks for a table, and -tokens=json
+// asks for JSON.
+type tokensFlag string
+
+func (f *tokensFlag) String() string   { return string(*f) }
+func (f *tokensFlag) IsBoolFlag() bool { return true }
+
+func (f *tokensFlag) Set(s string) error {
+	switch s {
+	case "true", "table":
+		*f = "table"
+	case "false":
+		*f = ""
+	case "json":
+		*f = "json"
+	default:
+		return fmt.Errorf("want table or json")
+	}
+	return nil
+}
+
 func main() {
-	// TODO Flags?
-	flag.Parse()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(fmtMain(os.Args[2:]))
+		case "lsp":
+			os.Exit(lspMain(os.Args[2:]))
+		case "debug":
+			os.Exit(debugMain(os.Args[2:]))
+		case "dap":
+			os.Exit(dapMain(os.Args[2:]))
+		}
+	}
+
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: ilox [flags] [script]\n       ilox fmt [-w] [-d] [files...]\n       ilox lsp\n       ilox debug script\n       ilox dap\n")
+		flag.PrintDefaults()
+	}
+	files := flag.String("files", "none", "file access for scripts within the current directory: none, read or write")
+	deterministic := flag.Bool("deterministic", false, "fix the clock and random seed and disable file access, for reproducible output")
+	seed := flag.Int64("seed", 0, "random seed in deterministic mode")
+	var tokens tokensFlag
+	flag.Var(&tokens, "tokens", "print the script's tokens as a table, or as json with -tokens=json, instead of running it")
+	ast := flag.Bool("ast", false, "print the script's syntax tree instead of running it, like -dump-ast=sexpr")
+	dumpAST := flag.String("dump-ast", "", "print the script's syntax tree as sexpr, lox or json instead of running it")
+	check := flag.Bool("check", false, "only scan and parse the script, reporting any syntax errors")
+	code := flag.String("e", "", "run the given code instead of a script")
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		os.Exit(parseStatus(err))
+	}
 	inputFile := flag.Arg(0)
+	if *ast && *dumpAST == "" {
+		*dumpAST = "sexpr"
+	}
+
+	opts := interpret.Options{Deterministic: *deterministic, Seed: *seed}
+	switch *files {
+	case "none":
+	case "read":
+		opts.Files = interpret.ReadOnlyFS(os.DirFS("."))
+	case "write":
+		opts.Files = interpret.DirFS(".")
+	default:
+		fmt.Fprintf(os.Stderr, "unknown file access %q\n", *files)
+		os.Exit(exitUsage)
+	}
+
+	modes := 0
+	for _, set := range []bool{tokens != "", *dumpAST != "", *check} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		fmt.Fprintf(os.Stderr, "only one of -tokens, -ast, -dump-ast and -check may be given\n")
+		os.Exit(exitUsage)
+	}
+	if *code != "" && inputFile != "" {
+		fmt.Fprintf(os.Stderr, "-e cannot be used with a script\n")
+		os.Exit(exitUsage)
+	}
+
+	// Without a script or -e, the only thing to do is run the prompt.
+	if *code == "" && inputFile == "" {
+		if modes > 0 {
+			fmt.Fprintf(os.Stderr, "-tokens, -ast, -dump-ast and -check need a script or -e\n")
+			os.Exit(exitUsage)
+		}
+		if err := lox.RunPrompt(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(exitIOErr)
+		}
+		return
+	}
+
+	src, path := *code, "<command line>"
+	if inputFile != "" {
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(exitNoInput)
+		}
+		src, path = string(data), inputFile
+	}
 
 	var err error
-	if inputFile == "" {
-		err = lox.RunPrompt()
-	} else {
-		err = lox.RunFile(inputFile)
+	switch {
+	case tokens != "":
+		err = lox.DumpTokens(src, string(tokens), os.Stdout)
+	case *dumpAST != "":
+		err = lox.DumpAST(src, *dumpAST, os.Stdout)
+	case *check:
+		err = lox.Check(src)
+	default:
+		err = lox.RunSource(src, path, opts)
+	}
+
+	os.Exit(exitStatus(err))
+}
+
+// parseStatus is the exit status for an error parsing flags, which the flag
+// package has already reported. Asking for help is not a failure.
+func parseStatus(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
 	}
+	return exitUsage
+}
 
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "%v\n", err)
+// exitStatus is the exit status for an error from running a script. Errors in
+// the script have already been reported; others are printed.
+func exitStatus(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, lox.ErrStatic):
+		return exitDataErr
+	case errors.Is(err, lox.ErrRuntime):
+		return exitSoftware
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission):
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitNoInput
 	}
+	fmt.Fprintf(os.Stderr, "%v\n", err)
+	return exitUsage
 }
@@ -0,0 +1,370 @@
+// Package astjson converts Lox syntax trees to and from JSON, so that other
+// tools can read parsed programs.
+//
+// A program is an array of statements. Every node is an object whose "kind"
+// is the name of its type, like "Binary" or "Print", followed by its fields
+// named as in Go but with a lowercase initial. Missing nodes are null, and nil
+// lists are null where empty lists are [].
+//
+// Tokens are objects with their "type", "lexeme", "line" and "char", and a
+// "literal" if they have one. Missing tokens are null.
+//
+// Literal values are null, booleans and strings as in JSON. Numbers are
+// objects giving their exact value as a string, and their type: "int",
+// "bigint", "float" or "decimal". For example 0.1d is
+// {"type": "decimal", "value": "1/10"}.
+//
+// The codec finds fields by reflection and node types in the Kinds tables that
+// genexpr writes, so new kinds of node need no changes here.
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var (
+	exprType  = reflect.TypeOf((*expr.Type)(nil)).Elem()
+	stmtType  = reflect.TypeOf((*stmt.Type)(nil)).Elem()
+	tokenType = reflect.TypeOf(tok.Token{})
+
+	// tokenTypes finds token types by name.
+	tokenTypes = make(map[string]tok.TokenType)
+)
+
+func init() {
+	for t := tok.INVALID; t <= tok.EOF; t++ {
+		tokenTypes[t.String()] = t
+	}
+}
+
+// Marshal encodes a program as JSON.
+func Marshal(stmts []stmt.Type) ([]byte, error) {
+	v, err := encode(reflect.ValueOf(stmts))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// MarshalIndent is like Marshal but indents the output like json.MarshalIndent.
+func MarshalIndent(stmts []stmt.Type, prefix, indent string) ([]byte, error) {
+	data, err := Marshal(stmts)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, prefix, indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// MarshalTokens encodes a list of tokens as JSON.
+func MarshalTokens(toks []tok.Token) ([]byte, error) {
+	v, err := encode(reflect.ValueOf(toks))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes a program encoded by Marshal.
+func Unmarshal(data []byte) ([]stmt.Type, error) {
+	var stmts []stmt.Type
+	if err := decode(data, reflect.ValueOf(&stmts).Elem()); err != nil {
+		return nil, err
+	}
+	return stmts, nil
+}
+
+// object is a JSON object that keeps its keys in order.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for j, m := range o {
+		if j > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// fieldName is the JSON name of a node's field.
+func fieldName(name string) string {
+	r, n := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[n:]
+}
+
+func isNode(t reflect.Type) bool {
+	return t.Implements(exprType) || t.Implements(stmtType)
+}
+
+// encode converts part of a syntax tree into values encoding/json can write.
+func encode(v reflect.Value) (interface{}, error) {
+	switch {
+	case v.Kind() == reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if isNode(v.Elem().Type()) {
+			return encode(v.Elem())
+		}
+		return encodeLiteral(v.Elem().Interface())
+
+	case v.Type() == tokenType:
+		return encodeToken(v.Interface().(tok.Token))
+
+	case v.Kind() == reflect.Ptr && isNode(v.Type()):
+		if v.IsNil() {
+			return nil, nil
+		}
+		obj := object{{key: "kind", value: v.Elem().Type().Name()}}
+		for j := 0; j < v.Elem().NumField(); j++ {
+			value, err := encode(v.Elem().Field(j))
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: fieldName(v.Elem().Type().Field(j).Name), value: value})
+		}
+		return obj, nil
+
+	case v.Kind() == reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		list := make([]interface{}, v.Len())
+		for j := range list {
+			el, err := encode(v.Index(j))
+			if err != nil {
+				return nil, err
+			}
+			list[j] = el
+		}
+		return list, nil
+
+	case v.Kind() == reflect.Bool:
+		return v.Bool(), nil
+	}
+	return nil, fmt.Errorf("astjson: cannot encode %s", v.Type())
+}
+
+func encodeToken(t tok.Token) (interface{}, error) {
+	if t == (tok.Token{}) {
+		return nil, nil
+	}
+	obj := object{
+		{key: "type", value: t.Typ.String()},
+		{key: "lexeme", value: t.Lexeme},
+		{key: "line", value: t.Line},
+		{key: "char", value: t.Char},
+	}
+	if t.Lit != nil {
+		lit, err := encodeLiteral(t.Lit)
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, member{key: "literal", value: lit})
+	}
+	return obj, nil
+}
+
+func encodeLiteral(v interface{}) (interface{}, error) {
+	number := func(typ, value string) object {
+		return object{{key: "type", value: typ}, {key: "value", value: value}}
+	}
+	switch v := v.(type) {
+	case nil, bool, string:
+		return v, nil
+	case int64:
+		return number("int", strconv.FormatInt(v, 10)), nil
+	case *big.Int:
+		return number("bigint", v.String()), nil
+	case float64:
+		return number("float", strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case *big.Rat:
+		return number("decimal", v.RatString()), nil
+	}
+	return nil, fmt.Errorf("astjson: cannot encode literal %T", v)
+}
+
+// decode sets v, which may be any part of a syntax tree, from data.
+func decode(data json.RawMessage, v reflect.Value) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Interface:
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) == nil && fields["kind"] != nil {
+			return decodeNode(fields, v)
+		}
+		lit, err := decodeLiteral(data)
+		if err != nil {
+			return err
+		}
+		if lit != nil && !reflect.TypeOf(lit).AssignableTo(v.Type()) {
+			return fmt.Errorf("astjson: %s is not a %s", data, v.Type())
+		}
+		if lit != nil {
+			v.Set(reflect.ValueOf(lit))
+		}
+		return nil
+
+	case v.Type() == tokenType:
+		t, err := decodeToken(data)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+
+	case v.Kind() == reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fmt.Errorf("astjson: %v", err)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for j := range items {
+			if err := decode(items[j], slice.Index(j)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+
+	case v.Kind() == reflect.Bool:
+		var b bool
+		if err := json.Unmarshal(data, &b); err != nil {
+			return fmt.Errorf("astjson: %v", err)
+		}
+		v.SetBool(b)
+		return nil
+	}
+	return fmt.Errorf("astjson: cannot decode %s", v.Type())
+}
+
+// decodeNode makes a node of the kind named in fields and stores it in v, an
+// interface. Nodes in interface{} fields, which are the bodies of lambdas, are
+// statements.
+func decodeNode(fields map[string]json.RawMessage, v reflect.Value) error {
+	var kind string
+	if err := json.Unmarshal(fields["kind"], &kind); err != nil {
+		return fmt.Errorf("astjson: %v", err)
+	}
+
+	var node interface{}
+	if v.Type() == exprType {
+		if mk, ok := expr.Kinds[kind]; ok {
+			node = mk()
+		}
+	} else if mk, ok := stmt.Kinds[kind]; ok {
+		node = mk()
+	}
+	if node == nil || !reflect.TypeOf(node).AssignableTo(v.Type()) {
+		return fmt.Errorf("astjson: unknown kind %q for %s", kind, v.Type())
+	}
+
+	elem := reflect.ValueOf(node).Elem()
+	for j := 0; j < elem.NumField(); j++ {
+		name := fieldName(elem.Type().Field(j).Name)
+		if data, ok := fields[name]; ok {
+			if err := decode(data, elem.Field(j)); err != nil {
+				return fmt.Errorf("%v in %s.%s", err, kind, name)
+			}
+		}
+	}
+	v.Set(reflect.ValueOf(node))
+	return nil
+}
+
+func decodeToken(data json.RawMessage) (tok.Token, error) {
+	var t struct {
+		Type    string
+		Lexeme  string
+		Line    int
+		Char    int
+		Literal json.RawMessage
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return tok.Token{}, fmt.Errorf("astjson: %v", err)
+	}
+	typ, ok := tokenTypes[t.Type]
+	if !ok {
+		return tok.Token{}, fmt.Errorf("astjson: unknown token type %q", t.Type)
+	}
+
+	var lit interface{}
+	if t.Literal != nil {
+		var err error
+		if lit, err = decodeLiteral(t.Literal); err != nil {
+			return tok.Token{}, err
+		}
+	}
+	return tok.Token{Typ: typ, Lexeme: t.Lexeme, Lit: lit, Line: t.Line, Char: t.Char}, nil
+}
+
+func decodeLiteral(data json.RawMessage) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("astjson: %v", err)
+	}
+	switch v.(type) {
+	case nil, bool, string:
+		return v, nil
+	}
+
+	var number struct{ Type, Value string }
+	if err := json.Unmarshal(data, &number); err != nil {
+		return nil, fmt.Errorf("astjson: invalid literal %s", data)
+	}
+	var val interface{}
+	var ok bool
+	switch number.Type {
+	case "int":
+		n, err := strconv.ParseInt(number.Value, 10, 64)
+		val, ok = n, err == nil
+	case "bigint":
+		val, ok = new(big.Int).SetString(number.Value, 10)
+	case "float":
+		f, err := strconv.ParseFloat(number.Value, 64)
+		val, ok = f, err == nil
+	case "decimal":
+		val, ok = new(big.Rat).SetString(number.Value)
+	}
+	if !ok {
+		return nil, fmt.Errorf("astjson: invalid literal %s", data)
+	}
+	return val, nil
+}
@@ -0,0 +1,113 @@
+package astjson
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+)
+
+func Example() {
+	fake := errtrack.NewFake()
+	stmts := parse.New(fake.Tracker, scan.New(fake.Tracker, `print 1.5;`).Tokens()).AST()
+
+	data, _ := MarshalIndent(stmts, "", "  ")
+	fmt.Println(string(data))
+
+	// Output:
+	// [
+	//   {
+	//     "kind": "Print",
+	//     "expr": {
+	//       "kind": "Literal",
+	//       "value": {
+	//         "type": "float",
+	//         "value": "1.5"
+	//       }
+	//     }
+	//   }
+	// ]
+}
+
+// TestRoundTrip checks that every Lox file in the repository decodes to
+// exactly what was encoded.
+func TestRoundTrip(t *testing.T) {
+	var paths []string
+	for _, pattern := range []string{"../testdata/*.lox", "../format/testdata/*.input"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no Lox files found")
+	}
+
+	for _, path := range paths {
+		path := path
+		t.Run(path, func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := parseString(t, string(src))
+
+			data, err := Marshal(want)
+			if err != nil {
+				t.Fatalf("cannot encode: %v", err)
+			}
+			got, err := Unmarshal(data)
+			if err != nil {
+				t.Fatalf("cannot decode: %v", err)
+			}
+			if diff := cmp.Diff(got, want, compareBig, compareRat); diff != "" {
+				t.Errorf("round trip changed the program (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+var (
+	compareBig = cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 })
+	compareRat = cmp.Comparer(func(a, b *big.Rat) bool { return a.Cmp(b) == 0 })
+)
+
+func TestUnmarshalErrors(t *testing.T) {
+	table := map[string]string{
+		"not json":        `[`,
+		"not a list":      `{"kind": "Print"}`,
+		"unknown kind":    `[{"kind": "Loop"}]`,
+		"expr as stmt":    `[{"kind": "Literal", "value": null}]`,
+		"stmt as expr":    `[{"kind": "Print", "expr": {"kind": "Print"}}]`,
+		"literal as expr": `[{"kind": "Print", "expr": 1}]`,
+		"bad token":       `[{"kind": "Var", "name": {"type": "NAME"}}]`,
+		"bad number":      `[{"kind": "Print", "expr": {"kind": "Literal", "value": {"type": "int", "value": "1.5"}}}]`,
+		"bad field":       `[{"kind": "Block", "statements": true}]`,
+	}
+	for name, in := range table {
+		t.Run(name, func(t *testing.T) {
+			if got, err := Unmarshal([]byte(in)); err == nil {
+				t.Errorf("decoded %s to %v without error", in, got)
+			}
+		})
+	}
+}
+
+func parseString(t *testing.T, in string) []stmt.Type {
+	t.Helper()
+	fake := errtrack.NewFake()
+	stmts := parse.New(fake.Tracker, scan.New(fake.Tracker, in).Tokens()).AST()
+	if fake.Tracker.HadError() {
+		t.Fatalf("could not parse %q: %s", in, fake.Errors())
+	}
+	return stmts
+}
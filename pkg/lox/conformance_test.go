@@ -0,0 +1,66 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+)
+
+var (
+	expectOutput = regexp.MustCompile(`// expect: ?(.*)`)
+	expectError  = regexp.MustCompile(`// expect runtime error: (.*)`)
+)
+
+// TestConformance runs each script in testdata in deterministic mode. Every
+// line a script prints must be noted in order with a "// expect: " comment,
+// and a "// expect runtime error: " comment notes part of an error message.
+func TestConformance(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.lox")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		path := path
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".lox"), func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want []string
+			for _, m := range expectOutput.FindAllStringSubmatch(string(src), -1) {
+				want = append(want, m[1]+"\n")
+			}
+			wantErr := expectError.FindStringSubmatch(string(src))
+
+			var stdout, stderr bytes.Buffer
+			err = run(string(src), moduleName(path), interpret.Options{
+				Stdout:        &stdout,
+				Stderr:        &stderr,
+				Deterministic: true,
+			})
+
+			if diff := cmp.Diff(stdout.String(), strings.Join(want, "")); diff != "" {
+				t.Errorf("incorrect output (-got,+want): %s", diff)
+			}
+			if got, want := errors.Is(err, ErrRuntime), wantErr != nil; got != want {
+				t.Errorf("got error %v, want runtime error %t", err, want)
+			}
+			switch {
+			case wantErr == nil && stderr.Len() > 0:
+				t.Errorf("unexpected error: %q", stderr.String())
+			case wantErr != nil && !strings.Contains(stderr.String(), wantErr[1]):
+				t.Errorf("got error %q, want it to contain %q", stderr.String(), wantErr[1])
+			}
+		})
+	}
+}
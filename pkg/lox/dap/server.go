@@ -0,0 +1,602 @@
+// Package dap is a Debug Adapter Protocol server for Lox, so editors can debug
+// programs: set breakpoints, step through them, and look at their stack and
+// variables.
+//
+// A session debugs the one program its client launches. The program runs in
+// its own goroutine, shown to the client as a single thread. While it is
+// paused, requests that inspect it are carried out in that goroutine, and
+// breakpoints can only be set in the program itself, not in the modules it
+// imports.
+package dap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/debug"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/wire"
+)
+
+var (
+	errNotLaunched = errors.New("No program has been launched.")
+	errNotPaused   = errors.New("The program is not paused.")
+	errNotRunning  = errors.New("The program is not running.")
+)
+
+// threadID is the program's only thread.
+const threadID = 1
+
+// reasons are the protocol's names for why a program stopped.
+var reasons = map[debug.Reason]string{
+	debug.Entry:       "entry",
+	debug.Breakpoint:  "breakpoint",
+	debug.Step:        "step",
+	debug.Interrupted: "pause",
+}
+
+// Server serves one client.
+type Server struct {
+	loader func(program string) (interpret.ModuleLoader, string)
+
+	mu  sync.Mutex // guards seq and writes to out
+	seq int
+	out *wire.Writer
+
+	breakpoints  map[string][]int // by source path
+	configured   bool
+	prog         *program
+	after        func() // runs once the current response is sent
+	disconnected bool
+}
+
+// NewServer makes a server that reads programs and the modules they import
+// through the loader that loader returns for the program path in a launch
+// request, along with the loader's name for the program.
+func NewServer(loader func(program string) (interpret.ModuleLoader, string)) *Server {
+	return &Server{
+		loader:      loader,
+		breakpoints: make(map[string][]int),
+	}
+}
+
+// handlers answer requests by command.
+var handlers = map[string]func(s *Server, args json.RawMessage) (interface{}, error){
+	"initialize":        (*Server).initialize,
+	"launch":            (*Server).launch,
+	"setBreakpoints":    (*Server).setBreakpoints,
+	"configurationDone": (*Server).configurationDone,
+	"threads":           (*Server).threads,
+	"stackTrace":        (*Server).stackTrace,
+	"scopes":            (*Server).scopes,
+	"variables":         (*Server).variables,
+	"evaluate":          (*Server).evaluate,
+	"continue":          resume(debug.Continue),
+	"next":              resume(debug.StepOver),
+	"stepIn":            resume(debug.StepIn),
+	"stepOut":           resume(debug.StepOut),
+	"pause":             (*Server).pause,
+	"terminate":         (*Server).terminate,
+	"disconnect":        (*Server).disconnect,
+}
+
+// Serve reads requests from r and writes responses and events to w until the
+// client disconnects or hangs up. The program is stopped before Serve returns.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	in := wire.NewReader(r)
+	s.out = wire.NewWriter(w)
+	defer s.stop()
+	for !s.disconnected {
+		body, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := s.handle(body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handle answers one request.
+func (s *Server) handle(body []byte) error {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return fmt.Errorf("dap: bad message: %w", err)
+	}
+	if req.Type != "request" {
+		return nil // the server sends no requests, so expects no responses
+	}
+
+	var result interface{}
+	var err error
+	if handler, ok := handlers[req.Command]; ok {
+		result, err = handler(s, req.Arguments)
+	} else {
+		err = fmt.Errorf("Unknown command %q.", req.Command)
+	}
+
+	resp := response{Type: "response", RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: result}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+	if err := s.send(func(seq int) interface{} { resp.Seq = seq; return resp }); err != nil {
+		return err
+	}
+	if s.after != nil {
+		after := s.after
+		s.after = nil
+		after()
+	}
+	return nil
+}
+
+// send writes the message msg makes with the next sequence number.
+func (s *Server) send(msg func(seq int) interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.out.Write(msg(s.seq))
+}
+
+// event sends an event. Events sent from the program's goroutine cannot report
+// errors, which the next response will find anyway.
+func (s *Server) event(name string, body interface{}) {
+	s.send(func(seq int) interface{} {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+func unmarshal(args json.RawMessage, v interface{}) error {
+	if len(args) == 0 {
+		return nil
+	}
+	return json.Unmarshal(args, v)
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	s.after = func() { s.event("initialized", nil) }
+	return map[string]interface{}{
+		"supportsConfigurationDoneRequest": true,
+		"supportsEvaluateForHovers":        true,
+		"supportsTerminateRequest":         true,
+	}, nil
+}
+
+// launch loads the program. It starts once the client is done configuring.
+func (s *Server) launch(args json.RawMessage) (interface{}, error) {
+	var params LaunchArguments
+	if err := unmarshal(args, &params); err != nil {
+		return nil, err
+	}
+	if s.prog != nil {
+		return nil, errors.New("A program has already been launched.")
+	}
+	prog, err := s.load(params)
+	if err != nil {
+		return nil, err
+	}
+	s.prog = prog
+	s.setProgramBreakpoints()
+	if s.configured {
+		s.after = prog.start
+	}
+	return nil, nil
+}
+
+func (s *Server) configurationDone(json.RawMessage) (interface{}, error) {
+	s.configured = true
+	if s.prog != nil {
+		s.after = s.prog.start
+	}
+	return nil, nil
+}
+
+func (s *Server) setBreakpoints(args json.RawMessage) (interface{}, error) {
+	var params SetBreakpointsArguments
+	if err := unmarshal(args, &params); err != nil {
+		return nil, err
+	}
+	var lines []int
+	breakpoints := make([]Breakpoint, 0, len(params.Breakpoints))
+	for _, bp := range params.Breakpoints {
+		lines = append(lines, bp.Line)
+		verified := Breakpoint{Verified: true, Line: bp.Line}
+		switch {
+		case s.prog == nil:
+		case params.Source.Path != s.prog.path:
+			verified = Breakpoint{Line: bp.Line, Message: "Breakpoints can only be set in the launched program."}
+		case s.prog.debugger != nil && !s.prog.debugger.Breakable(bp.Line):
+			verified = Breakpoint{Line: bp.Line, Message: "No statement starts on this line."}
+		}
+		breakpoints = append(breakpoints, verified)
+	}
+	s.breakpoints[params.Source.Path] = lines
+	s.setProgramBreakpoints()
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+// setProgramBreakpoints gives the debugger the breakpoints in the program.
+func (s *Server) setProgramBreakpoints() {
+	if s.prog == nil || s.prog.debugger == nil {
+		return
+	}
+	d := s.prog.debugger
+	for _, line := range d.Breakpoints() {
+		d.ClearBreakpoint(line)
+	}
+	for _, line := range s.breakpoints[s.prog.path] {
+		d.SetBreakpoint(line)
+	}
+}
+
+func (s *Server) threads(json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}}, nil
+}
+
+func (s *Server) stackTrace(args json.RawMessage) (interface{}, error) {
+	var params StackTraceArguments
+	if err := unmarshal(args, &params); err != nil {
+		return nil, err
+	}
+	return s.inspect(func(prog *program, p *debug.Pause) (interface{}, error) {
+		frames := p.Frames()
+		stack := []StackFrame{}
+		for j := params.StartFrame; j < len(frames); j++ {
+			if params.Levels > 0 && len(stack) == params.Levels {
+				break
+			}
+			stack = append(stack, StackFrame{
+				ID:     j,
+				Name:   debug.FrameName(frames[j]),
+				Source: &prog.source,
+				Line:   p.FrameLine(j),
+				Column: 1,
+			})
+		}
+		return map[string]interface{}{"stackFrames": stack, "totalFrames": len(frames)}, nil
+	})
+}
+
+func (s *Server) scopes(args json.RawMessage) (interface{}, error) {
+	var params ScopesArguments
+	if err := unmarshal(args, &params); err != nil {
+		return nil, err
+	}
+	return s.inspect(func(prog *program, p *debug.Pause) (interface{}, error) {
+		if err := checkFrame(p, params.FrameID); err != nil {
+			return nil, err
+		}
+		scopes := []Scope{}
+		chain := p.Scopes(params.FrameID)
+		for j, scope := range chain {
+			name := "Enclosing"
+			switch {
+			case scope.Global:
+				name = "Builtins"
+			case j+1 < len(chain) && chain[j+1].Global:
+				name = "Globals"
+			case j == 0:
+				name = "Locals"
+			}
+			scopes = append(scopes, Scope{Name: name, VariablesReference: prog.handle(scope.Env), Expensive: scope.Global})
+		}
+		return map[string]interface{}{"scopes": scopes}, nil
+	})
+}
+
+func (s *Server) variables(args json.RawMessage) (interface{}, error) {
+	var params VariablesArguments
+	if err := unmarshal(args, &params); err != nil {
+		return nil, err
+	}
+	return s.inspect(func(prog *program, p *debug.Pause) (interface{}, error) {
+		ref := params.VariablesReference
+		if ref < 1 || ref > len(prog.handles) {
+			return nil, fmt.Errorf("No variables with reference %d.", ref)
+		}
+		vars := []Variable{}
+		switch val := prog.handles[ref-1].(type) {
+		case *interpret.Env:
+			for _, name := range val.Names() {
+				v, _ := val.Lookup(name)
+				vars = append(vars, prog.variable(name, v))
+			}
+		case *interpret.List:
+			for j, v := range val.Elements {
+				vars = append(vars, prog.variable(fmt.Sprint(j), v))
+			}
+		case *interpret.Map:
+			for _, key := range val.Keys() {
+				v, _ := val.Get(key)
+				vars = append(vars, prog.variable(debug.Show(key), v))
+			}
+		}
+		return map[string]interface{}{"variables": vars}, nil
+	})
+}
+
+func (s *Server) evaluate(args json.RawMessage) (interface{}, error) {
+	var params EvaluateArguments
+	if err := unmarshal(args, &params); err != nil {
+		return nil, err
+	}
+	return s.inspect(func(prog *program, p *debug.Pause) (interface{}, error) {
+		if err := checkFrame(p, params.FrameID); err != nil {
+			return nil, err
+		}
+		val, err := p.Eval(params.Expression, params.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		v := prog.variable("", val)
+		return EvaluateResult{Result: v.Value, VariablesReference: v.VariablesReference}, nil
+	})
+}
+
+func checkFrame(p *debug.Pause, frame int) error {
+	if frame < 0 || frame >= len(p.Frames()) {
+		return fmt.Errorf("No frame %d.", frame)
+	}
+	return nil
+}
+
+// resume makes a handler that sends the paused program on its way.
+func resume(action debug.Action) func(s *Server, args json.RawMessage) (interface{}, error) {
+	return func(s *Server, args json.RawMessage) (interface{}, error) {
+		if s.prog == nil || !s.prog.isPaused() {
+			return nil, errNotPaused
+		}
+		prog := s.prog
+		s.after = func() { prog.resume(action) }
+		if action == debug.Continue {
+			return map[string]interface{}{"allThreadsContinued": true}, nil
+		}
+		return nil, nil
+	}
+}
+
+func (s *Server) pause(json.RawMessage) (interface{}, error) {
+	if s.prog == nil || !s.prog.isRunning() || s.prog.debugger == nil {
+		return nil, errNotRunning
+	}
+	s.prog.debugger.Interrupt()
+	return nil, nil
+}
+
+// terminate stops the program, which ends the session when the client sees
+// the terminated event.
+func (s *Server) terminate(json.RawMessage) (interface{}, error) {
+	if s.prog == nil {
+		return nil, errNotLaunched
+	}
+	s.prog.cancel()
+	return nil, nil
+}
+
+func (s *Server) disconnect(json.RawMessage) (interface{}, error) {
+	s.stop()
+	s.disconnected = true
+	return nil, nil
+}
+
+// stop stops the program and waits for it to finish, if it was started.
+func (s *Server) stop() {
+	if s.prog == nil || s.prog.done == nil {
+		return
+	}
+	s.prog.cancel()
+	<-s.prog.done
+}
+
+// inspect runs fn in the goroutine of the paused program and returns its
+// results.
+func (s *Server) inspect(fn func(prog *program, p *debug.Pause) (interface{}, error)) (result interface{}, err error) {
+	prog := s.prog
+	if prog == nil || !prog.isPaused() {
+		return nil, errNotPaused
+	}
+	done := make(chan struct{})
+	cmd := command{inspect: func(p *debug.Pause) {
+		defer close(done)
+		result, err = fn(prog, p)
+	}}
+	select {
+	case prog.commands <- cmd:
+	case <-prog.done:
+		return nil, errNotPaused
+	}
+	<-done
+	return result, err
+}
+
+// load reads and parses a program.
+func (s *Server) load(params LaunchArguments) (*program, error) {
+	loader, name := s.loader(params.Program)
+	src, err := loader.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read %s: %v", params.Program, err)
+	}
+
+	var errs bytes.Buffer
+	tracker := errtrack.NewWithOutput(&errs)
+	parser := parse.New(tracker, scan.New(tracker, string(src)).Tokens())
+	ast := parser.AST()
+	if tracker.HadError() {
+		return nil, errors.New(strings.TrimSpace(errs.String()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	prog := &program{
+		server:   s,
+		path:     params.Program,
+		source:   Source{Name: path.Base(name), Path: params.Program},
+		ast:      ast,
+		ctx:      ctx,
+		cancel:   cancel,
+		commands: make(chan command),
+	}
+
+	// Runtime errors, including syntax errors in imported modules, are output.
+	tracker = errtrack.NewWithOutput(output{s, "stderr"})
+	prog.interp = interpret.New(tracker, interpret.Options{
+		Stdin:  strings.NewReader(""), // standard input carries the protocol
+		Stdout: output{s, "stdout"},
+		Stderr: output{s, "stderr"},
+	})
+	prog.interp.SetLoader(loader, name)
+	if !params.NoDebug {
+		prog.debugger = debug.New(prog.interp, parser, prog.onPause, params.StopOnEntry)
+	}
+	return prog, nil
+}
+
+// output sends what a program writes as output events.
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o output) Write(b []byte) (int, error) {
+	o.s.event("output", OutputEvent{Category: o.category, Output: string(b)})
+	return len(b), nil
+}
+
+// program is a launched program.
+type program struct {
+	server   *Server
+	path     string
+	source   Source
+	ast      []stmt.Type
+	interp   *interpret.Interpreter
+	debugger *debug.Debugger // nil without debugging
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	commands chan command
+	done     chan struct{} // closed when the program ends
+
+	mu      sync.Mutex
+	running bool
+	paused  bool
+
+	// handles are the values the client may ask for the variables of, by
+	// reference minus one. They are only used in the program's goroutine
+	// and are forgotten when it resumes.
+	handles []interface{}
+}
+
+// command is sent to a paused program, to inspect it or, if inspect is nil,
+// to resume it.
+type command struct {
+	inspect func(p *debug.Pause)
+	action  debug.Action
+}
+
+// start runs the program in a new goroutine.
+func (prog *program) start() {
+	if prog.done != nil {
+		return
+	}
+	prog.done = make(chan struct{})
+	prog.setState(true, false)
+	go func() {
+		var err error
+		if prog.debugger != nil {
+			err = prog.debugger.Run(prog.ctx, prog.ast)
+		} else {
+			err = prog.interp.InterpretContext(prog.ctx, prog.ast)
+		}
+		prog.setState(false, false)
+		exitCode := 0
+		if err != nil && !errors.Is(err, debug.ErrStopped) {
+			exitCode = 1
+		}
+		prog.server.event("exited", ExitedEvent{ExitCode: exitCode})
+		prog.server.event("terminated", nil)
+		close(prog.done)
+	}()
+}
+
+// onPause is the debugger's handler. It carries out commands from the server
+// until one resumes the program, which stops if the session ends.
+func (prog *program) onPause(p *debug.Pause) debug.Action {
+	prog.handles = nil
+	prog.setState(true, true)
+	defer prog.setState(true, false)
+	prog.server.event("stopped", StoppedEvent{Reason: reasons[p.Reason], ThreadID: threadID, AllThreadsStopped: true})
+	for {
+		select {
+		case cmd := <-prog.commands:
+			if cmd.inspect == nil {
+				return cmd.action
+			}
+			cmd.inspect(p)
+		case <-prog.ctx.Done():
+			return debug.Stop
+		}
+	}
+}
+
+// resume sends the paused program on. It is no longer paused once resume
+// returns, so that requests that follow do not wait for the next pause.
+func (prog *program) resume(action debug.Action) {
+	prog.setState(true, false)
+	select {
+	case prog.commands <- command{action: action}:
+	case <-prog.done:
+	}
+}
+
+func (prog *program) setState(running, paused bool) {
+	prog.mu.Lock()
+	defer prog.mu.Unlock()
+	prog.running, prog.paused = running, paused
+}
+
+func (prog *program) isRunning() bool {
+	prog.mu.Lock()
+	defer prog.mu.Unlock()
+	return prog.running
+}
+
+func (prog *program) isPaused() bool {
+	prog.mu.Lock()
+	defer prog.mu.Unlock()
+	return prog.paused
+}
+
+// handle returns a reference to val's variables.
+func (prog *program) handle(val interface{}) int {
+	prog.handles = append(prog.handles, val)
+	return len(prog.handles)
+}
+
+// variable describes a value. Lists and maps can be expanded.
+func (prog *program) variable(name string, val interface{}) Variable {
+	v := Variable{Name: name, Value: debug.Show(val)}
+	switch val := val.(type) {
+	case *interpret.List:
+		if len(val.Elements) > 0 {
+			v.VariablesReference = prog.handle(val)
+		}
+	case *interpret.Map:
+		if val.Len() > 0 {
+			v.VariablesReference = prog.handle(val)
+		}
+	}
+	return v
+}
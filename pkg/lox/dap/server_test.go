@@ -0,0 +1,329 @@
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/wire"
+)
+
+const src = `fn add(a, b) {
+  var sum = a + b;
+  return sum;
+}
+var list = [1, "two"];
+var x = add(1, 2);
+print x;
+print "done";
+`
+
+// message is any message from the server.
+type message struct {
+	Type       string
+	Event      string
+	RequestSeq int `json:"request_seq"`
+	Success    bool
+	Message    string
+	Body       json.RawMessage
+}
+
+// client talks to a server running in another goroutine.
+type client struct {
+	t      *testing.T
+	in     chan []byte // messages from the server
+	out    *wire.Writer
+	done   chan error
+	seq    int
+	events []message // events not yet waited for
+	output map[string]string
+}
+
+func newClient(t *testing.T, files fstest.MapFS) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &client{
+		t:      t,
+		in:     make(chan []byte, 100),
+		out:    wire.NewWriter(clientOut),
+		done:   make(chan error, 1),
+		output: make(map[string]string),
+	}
+	go func() {
+		r := wire.NewReader(clientIn)
+		for {
+			body, err := r.Read()
+			if err != nil {
+				close(c.in)
+				return
+			}
+			c.in <- body
+		}
+	}()
+	go func() {
+		loader := func(program string) (interpret.ModuleLoader, string) {
+			return &interpret.FSLoader{FS: files}, program
+		}
+		err := NewServer(loader).Serve(serverIn, serverOut)
+		serverOut.Close()
+		c.done <- err
+	}()
+	c.call("initialize", map[string]interface{}{"adapterID": "ilox"}, nil)
+	c.wait("initialized")
+	return c
+}
+
+// next returns the next message from the server. Output events are recorded
+// instead.
+func (c *client) next() message {
+	c.t.Helper()
+	for {
+		body, ok := <-c.in
+		if !ok {
+			c.t.Fatal("server hung up")
+		}
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			c.t.Fatal(err)
+		}
+		if msg.Event == "output" {
+			var out OutputEvent
+			json.Unmarshal(msg.Body, &out)
+			c.output[out.Category] += out.Output
+			continue
+		}
+		return msg
+	}
+}
+
+// call sends a request and decodes its response's body into body. It returns
+// the response's error message, if it failed.
+func (c *client) call(command string, args, body interface{}) string {
+	c.t.Helper()
+	c.seq++
+	if err := c.out.Write(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": args}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.next()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg.RequestSeq != c.seq {
+			c.t.Fatalf("got response to request %d, want %d", msg.RequestSeq, c.seq)
+		}
+		if !msg.Success {
+			return msg.Message
+		}
+		if body != nil {
+			if err := json.Unmarshal(msg.Body, body); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return ""
+	}
+}
+
+// wait waits for an event, skipping earlier ones, and returns its body.
+func (c *client) wait(event string) json.RawMessage {
+	c.t.Helper()
+	for {
+		var msg message
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Type == "event" && msg.Event == event {
+			return msg.Body
+		}
+	}
+}
+
+// stopped waits for the program to stop and returns why and where.
+func (c *client) stopped() (string, []string) {
+	c.t.Helper()
+	var ev StoppedEvent
+	json.Unmarshal(c.wait("stopped"), &ev)
+	var trace struct{ StackFrames []StackFrame }
+	c.call("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	var frames []string
+	for _, f := range trace.StackFrames {
+		frames = append(frames, fmt.Sprintf("%s@%s:%d", f.Name, f.Source.Path, f.Line))
+	}
+	return ev.Reason, frames
+}
+
+func (c *client) variables(ref int) map[string]Variable {
+	c.t.Helper()
+	var body struct{ Variables []Variable }
+	if msg := c.call("variables", VariablesArguments{VariablesReference: ref}, &body); msg != "" {
+		c.t.Fatal(msg)
+	}
+	vars := make(map[string]Variable)
+	for _, v := range body.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+func (c *client) disconnect() {
+	c.t.Helper()
+	c.call("disconnect", nil, nil)
+	if err := <-c.done; err != nil {
+		c.t.Error(err)
+	}
+}
+
+func TestSession(t *testing.T) {
+	c := newClient(t, fstest.MapFS{"main.lox": {Data: []byte(src)}})
+	c.call("launch", LaunchArguments{Program: "main.lox"}, nil)
+
+	var bps struct{ Breakpoints []Breakpoint }
+	c.call("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: "main.lox"},
+		Breakpoints: []SourceBreakpoint{{Line: 3}, {Line: 4}},
+	}, &bps)
+	want := []Breakpoint{{Verified: true, Line: 3}, {Line: 4, Message: "No statement starts on this line."}}
+	if diff := cmp.Diff(bps.Breakpoints, want); diff != "" {
+		t.Errorf("incorrect breakpoints (-got,+want): %s", diff)
+	}
+
+	c.call("configurationDone", nil, nil)
+	reason, frames := c.stopped()
+	if reason != "breakpoint" {
+		t.Errorf("stopped for %q, want breakpoint", reason)
+	}
+	if diff := cmp.Diff(frames, []string{"add@main.lox:3", "<script>@main.lox:6"}); diff != "" {
+		t.Errorf("incorrect stack (-got,+want): %s", diff)
+	}
+
+	var threads struct{ Threads []Thread }
+	c.call("threads", nil, &threads)
+	if diff := cmp.Diff(threads.Threads, []Thread{{ID: threadID, Name: "main"}}); diff != "" {
+		t.Errorf("incorrect threads (-got,+want): %s", diff)
+	}
+
+	var scopes struct{ Scopes []Scope }
+	c.call("scopes", ScopesArguments{FrameID: 0}, &scopes)
+	var names []string
+	for _, scope := range scopes.Scopes {
+		names = append(names, scope.Name)
+	}
+	if diff := cmp.Diff(names, []string{"Locals", "Globals", "Builtins"}); diff != "" {
+		t.Fatalf("incorrect scopes (-got,+want): %s", diff)
+	}
+
+	locals := c.variables(scopes.Scopes[0].VariablesReference)
+	if locals["sum"].Value != "3" || locals["a"].Value != "1" {
+		t.Errorf("got locals %v", locals)
+	}
+	globals := c.variables(scopes.Scopes[1].VariablesReference)
+	if globals["add"].Value != "<fn add>" || globals["list"].VariablesReference == 0 {
+		t.Errorf("got globals %v", globals)
+	}
+	elements := c.variables(globals["list"].VariablesReference)
+	if elements["0"].Value != "1" || elements["1"].Value != `"two"` {
+		t.Errorf("got list elements %v", elements)
+	}
+
+	var result EvaluateResult
+	c.call("evaluate", EvaluateArguments{Expression: "sum * 10", Context: "watch"}, &result)
+	if result.Result != "30" {
+		t.Errorf("got sum * 10 = %s, want 30", result.Result)
+	}
+	if msg := c.call("evaluate", EvaluateArguments{Expression: "nope"}, nil); !strings.Contains(msg, "Undefined variable") {
+		t.Errorf("evaluating an undefined name failed with %q", msg)
+	}
+
+	c.call("next", map[string]int{"threadId": threadID}, nil)
+	if reason, frames := c.stopped(); reason != "step" || frames[0] != "<script>@main.lox:7" {
+		t.Errorf("stepped over to %s at %v, want line 7", reason, frames)
+	}
+
+	c.call("continue", map[string]int{"threadId": threadID}, nil)
+	var exited ExitedEvent
+	json.Unmarshal(c.wait("exited"), &exited)
+	c.wait("terminated")
+	if exited.ExitCode != 0 || c.output["stdout"] != "3\ndone\n" {
+		t.Errorf("program exited with %d and output %q", exited.ExitCode, c.output["stdout"])
+	}
+	c.disconnect()
+}
+
+func TestStopOnEntry(t *testing.T) {
+	c := newClient(t, fstest.MapFS{"main.lox": {Data: []byte(src)}})
+	c.call("configurationDone", nil, nil)
+	c.call("launch", LaunchArguments{Program: "main.lox", StopOnEntry: true}, nil)
+	if reason, frames := c.stopped(); reason != "entry" || frames[0] != "<script>@main.lox:1" {
+		t.Errorf("stopped for %s at %v, want entry at line 1", reason, frames)
+	}
+	c.call("stepIn", map[string]int{"threadId": threadID}, nil)
+	if reason, frames := c.stopped(); reason != "step" || frames[0] != "<script>@main.lox:5" {
+		t.Errorf("stopped for %s at %v, want step at line 5", reason, frames)
+	}
+	c.call("terminate", nil, nil)
+	c.wait("terminated")
+	if msg := c.call("stackTrace", StackTraceArguments{ThreadID: threadID}, nil); msg != errNotPaused.Error() {
+		t.Errorf("stack trace after terminating failed with %q", msg)
+	}
+	c.disconnect()
+}
+
+func TestErrors(t *testing.T) {
+	c := newClient(t, fstest.MapFS{
+		"syntax.lox":  {Data: []byte("print (;")},
+		"runtime.lox": {Data: []byte(`print "before"; print nope;`)},
+	})
+	table := map[string]struct {
+		command string
+		args    interface{}
+		want    string
+	}{
+		"unknown command": {
+			command: "bogus",
+			want:    `Unknown command "bogus".`,
+		},
+		"not paused": {
+			command: "scopes",
+			args:    ScopesArguments{},
+			want:    errNotPaused.Error(),
+		},
+		"missing program": {
+			command: "launch",
+			args:    LaunchArguments{Program: "missing.lox"},
+			want:    "Could not read missing.lox: open missing.lox: file does not exist",
+		},
+		"syntax error": {
+			command: "launch",
+			args:    LaunchArguments{Program: "syntax.lox"},
+			want:    `[line 1:8] at ";": Expected expression.`,
+		},
+	}
+	for name, test := range table {
+		t.Run(name, func(t *testing.T) {
+			c.t = t
+			if msg := c.call(test.command, test.args, nil); msg != test.want {
+				t.Errorf("failed with %q, want %q", msg, test.want)
+			}
+		})
+	}
+	c.t = t
+
+	c.call("launch", LaunchArguments{Program: "runtime.lox", NoDebug: true}, nil)
+	c.call("configurationDone", nil, nil)
+	var exited ExitedEvent
+	json.Unmarshal(c.wait("exited"), &exited)
+	c.wait("terminated")
+	if exited.ExitCode != 1 || c.output["stdout"] != "before\n" || !strings.Contains(c.output["stderr"], "Undefined variable") {
+		t.Errorf("program exited with %d and output %v", exited.ExitCode, c.output)
+	}
+	c.disconnect()
+}
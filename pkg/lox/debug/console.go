@@ -0,0 +1,186 @@
+package debug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+)
+
+const consoleHelp = `commands:
+  c, continue        run until a breakpoint
+  s, step            step to the next statement, into calls
+  n, next            step to the next statement, over calls
+  o, out             step out of the current call
+  b, break LINE      set a breakpoint
+  clear LINE         remove a breakpoint
+  breakpoints        list breakpoints
+  bt, stack          show the calls in progress
+  f, frame N         select a frame of the stack
+  env                show the variables in each scope of the frame
+  p, print EXPR      evaluate an expression in the frame
+  l, list            show the source around the current line
+  q, quit            stop the program
+`
+
+// Console is a command line debugger. It is the handler of a Debugger, reading
+// commands whenever the program pauses.
+type Console struct {
+	lines []string
+	in    *bufio.Scanner
+	out   io.Writer
+	frame int // the selected frame
+}
+
+// NewConsole makes a console that debugs the program in src, reading commands
+// from in and writing to out. Its Handle method is the debugger's handler.
+func NewConsole(src string, in io.Reader, out io.Writer) *Console {
+	return &Console{
+		lines: strings.Split(src, "\n"),
+		in:    bufio.NewScanner(in),
+		out:   out,
+	}
+}
+
+// Handle shows where the program paused and reads commands until one resumes
+// it. The program stops if the input ends.
+func (c *Console) Handle(p *Pause) Action {
+	c.frame = 0
+	fmt.Fprintf(c.out, "%s at line %d\n", p.Reason, p.Line)
+	c.showLine(p.Line)
+
+	for {
+		fmt.Fprintf(c.out, "(debug) ")
+		if !c.in.Scan() {
+			fmt.Fprintln(c.out)
+			return Stop
+		}
+		cmd, arg := strings.TrimSpace(c.in.Text()), ""
+		if cmd == "" {
+			continue
+		}
+		if space := strings.IndexAny(cmd, " \t"); space >= 0 {
+			cmd, arg = cmd[:space], strings.TrimSpace(cmd[space:])
+		}
+
+		switch cmd {
+		case "c", "continue":
+			return Continue
+		case "s", "step":
+			return StepIn
+		case "n", "next":
+			return StepOver
+		case "o", "out":
+			return StepOut
+		case "q", "quit":
+			return Stop
+		case "b", "break":
+			if line, ok := c.lineArg(arg); ok {
+				p.debugger.SetBreakpoint(line)
+				fmt.Fprintf(c.out, "breakpoint at line %d\n", line)
+			}
+		case "clear":
+			if line, ok := c.lineArg(arg); ok {
+				p.debugger.ClearBreakpoint(line)
+			}
+		case "breakpoints":
+			for _, line := range p.debugger.Breakpoints() {
+				fmt.Fprintf(c.out, "line %d\n", line)
+			}
+		case "bt", "stack":
+			c.stack(p)
+		case "f", "frame":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 || n >= len(p.Frames()) {
+				fmt.Fprintf(c.out, "no frame %q\n", arg)
+				continue
+			}
+			c.frame = n
+			c.showLine(p.FrameLine(n))
+		case "env":
+			c.env(p)
+		case "p", "print":
+			val, err := p.Eval(arg, c.frame)
+			if err != nil {
+				fmt.Fprintf(c.out, "error: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(c.out, Show(val))
+		case "l", "list":
+			line := p.FrameLine(c.frame)
+			for n := line - 3; n <= line+3; n++ {
+				c.showLine(n)
+			}
+		case "h", "help":
+			fmt.Fprint(c.out, consoleHelp)
+		default:
+			fmt.Fprintf(c.out, "unknown command %q; try help\n", cmd)
+		}
+	}
+}
+
+func (c *Console) lineArg(arg string) (int, bool) {
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 || line > len(c.lines) {
+		fmt.Fprintf(c.out, "no line %q\n", arg)
+		return 0, false
+	}
+	return line, true
+}
+
+func (c *Console) showLine(line int) {
+	if line >= 1 && line <= len(c.lines) {
+		fmt.Fprintf(c.out, "%4d  %s\n", line, c.lines[line-1])
+	}
+}
+
+func (c *Console) stack(p *Pause) {
+	for j, frame := range p.Frames() {
+		marker := " "
+		if j == c.frame {
+			marker = "*"
+		}
+		fmt.Fprintf(c.out, "%s %d  %s at line %d\n", marker, j, FrameName(frame), p.FrameLine(j))
+	}
+}
+
+// FrameName names the function a frame is running.
+func FrameName(frame interpret.Frame) string {
+	switch {
+	case frame.Call.Line == 0:
+		return "<script>"
+	case frame.Name == "":
+		return "<fn>"
+	}
+	return frame.Name
+}
+
+// env shows each scope of the selected frame. Builtins are only counted.
+func (c *Console) env(p *Pause) {
+	for _, scope := range p.Scopes(c.frame) {
+		names := scope.Env.Names()
+		if scope.Global {
+			fmt.Fprintf(c.out, "globals: %d builtins\n", len(names))
+			continue
+		}
+		fmt.Fprintf(c.out, "scope:\n")
+		for _, name := range names {
+			val, _ := scope.Env.Lookup(name)
+			fmt.Fprintf(c.out, "  %s = %s\n", name, Show(val))
+		}
+	}
+}
+
+// Show prints a variable's value for a debugger.
+func Show(val interface{}) string {
+	switch val := val.(type) {
+	case interpret.Uninitialized:
+		return "<uninitialized>"
+	case string:
+		return strconv.Quote(val)
+	}
+	return interpret.Stringify(val)
+}
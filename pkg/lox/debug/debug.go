@@ -0,0 +1,286 @@
+// Package debug pauses Lox programs at breakpoints, steps through them, and
+// inspects their variables while they are paused.
+//
+// A Debugger runs one program through an interpreter's hooks. When
+// the program pauses, the debugger calls its handler in the program's
+// goroutine, and the program goes on when the handler returns. Only statements
+// of the program itself can be paused at; code in imported modules runs
+// without stopping.
+package debug
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// ErrStopped is returned by Run when a handler stops the program.
+var ErrStopped = errors.New("debug: program stopped")
+
+// Action says how a paused program should go on.
+type Action int
+
+const (
+	Continue Action = iota // until a breakpoint
+	StepIn                 // to the next statement
+	StepOver               // to the next statement in this call or its callers
+	StepOut                // to the next statement in a caller
+	Stop                   // stop the program
+)
+
+// Reason says why a program paused.
+type Reason int
+
+const (
+	Entry Reason = iota
+	Breakpoint
+	Step
+	Interrupted
+)
+
+func (r Reason) String() string {
+	switch r {
+	case Entry:
+		return "entry"
+	case Breakpoint:
+		return "breakpoint"
+	case Step:
+		return "step"
+	case Interrupted:
+		return "interrupted"
+	}
+	return "unknown"
+}
+
+// Handler is called when the program pauses, and returns how it goes on.
+type Handler func(p *Pause) Action
+
+// Debugger controls a program. Breakpoints may be changed from any goroutine.
+type Debugger struct {
+	interp  *interpret.Interpreter
+	parser  *parse.Parser
+	handler Handler
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	interrupted bool
+
+	action  Action
+	depth   int // number of frames when the action was chosen
+	reason  Reason
+	line    int   // line of the last statement seen in the current call
+	callers []int // line of the last statement seen in each caller
+}
+
+// New makes a debugger for the program parser parsed, to run in interp. The
+// program pauses before its first statement if stopOnEntry is set.
+func New(interp *interpret.Interpreter, parser *parse.Parser, handler Handler, stopOnEntry bool) *Debugger {
+	d := &Debugger{
+		interp:      interp,
+		parser:      parser,
+		handler:     handler,
+		breakpoints: make(map[int]bool),
+		action:      Continue,
+		reason:      Step,
+	}
+	if stopOnEntry {
+		d.action, d.reason = StepIn, Entry
+	}
+	return d
+}
+
+// Run runs the program's statements with the debugger attached. It returns
+// the program's runtime error, or ErrStopped if a handler stopped it.
+func (d *Debugger) Run(ctx context.Context, stmts []stmt.Type) (err error) {
+	d.interp.SetHooks(hooks{d: d})
+	defer d.interp.SetHooks(nil)
+	defer func() {
+		if r := recover(); r != nil {
+			if r != ErrStopped {
+				panic(r)
+			}
+			err = ErrStopped
+		}
+	}()
+	return d.interp.InterpretContext(ctx, stmts)
+}
+
+// SetBreakpoint adds a breakpoint at the start of a line.
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// ClearBreakpoint removes the breakpoint on a line, if there is one.
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, line)
+}
+
+// Breakpoints returns the lines with breakpoints, in order.
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Interrupt pauses the program at its next statement.
+func (d *Debugger) Interrupt() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.interrupted = true
+}
+
+// Breakable reports whether a breakpoint on a line can pause the program,
+// because a statement other than a block starts on it.
+func (d *Debugger) Breakable(line int) bool {
+	for _, st := range d.parser.Statements() {
+		if _, isBlock := st.(*stmt.Block); isBlock {
+			continue
+		}
+		if start, _ := d.Line(st); start == line {
+			return true
+		}
+	}
+	return false
+}
+
+// Line returns the line a statement of the program starts on, or false if the
+// statement is not part of the program.
+func (d *Debugger) Line(st stmt.Type) (int, bool) {
+	span, ok := d.parser.Span(st)
+	return span.Start.Line, ok
+}
+
+// hooks pause the program before its statements.
+type hooks struct {
+	interpret.NopHooks
+	d *Debugger
+}
+
+func (h hooks) OnStatement(st stmt.Type) {
+	h.d.onStatement(st)
+}
+
+// OnCall and OnReturn keep each call's line apart, so that every call of a
+// function arrives at its lines afresh, even when made from the same line.
+func (h hooks) OnCall(fn interpret.Callable, paren tok.Token, args []interface{}) {
+	if _, ok := fn.(*interpret.Function); ok {
+		h.d.callers = append(h.d.callers, h.d.line)
+		h.d.line = 0
+	}
+}
+
+func (h hooks) OnReturn(fn interpret.Callable, paren tok.Token, result interface{}) {
+	if _, ok := fn.(*interpret.Function); ok {
+		last := len(h.d.callers) - 1
+		h.d.line, h.d.callers = h.d.callers[last], h.d.callers[:last]
+	}
+}
+
+func (d *Debugger) onStatement(st stmt.Type) {
+	line, ok := d.Line(st)
+	if _, isBlock := st.(*stmt.Block); !ok || isBlock {
+		return // blocks pause at their first statement instead
+	}
+	depth := len(d.interp.Frames())
+
+	// A breakpoint pauses when a line is reached, not at each statement on it.
+	arrived := line != d.line
+	d.line = line
+	d.mu.Lock()
+	atBreakpoint := arrived && d.breakpoints[line]
+	interrupted := d.interrupted
+	d.interrupted = false
+	d.mu.Unlock()
+
+	reason := d.reason
+	switch {
+	case interrupted:
+		reason = Interrupted
+	case d.action == StepIn,
+		d.action == StepOver && depth <= d.depth,
+		d.action == StepOut && depth < d.depth:
+	case atBreakpoint:
+		reason = Breakpoint
+	default:
+		return
+	}
+
+	action := d.handler(&Pause{Reason: reason, Line: line, Stmt: st, debugger: d})
+	if action == Stop {
+		panic(ErrStopped)
+	}
+	d.action, d.depth, d.reason = action, depth, Step
+}
+
+// Pause is a paused program.
+type Pause struct {
+	Reason Reason
+	Line   int
+	Stmt   stmt.Type // the statement about to run
+
+	debugger *Debugger
+	frames   []interpret.Frame
+}
+
+// Frames returns the calls in progress, innermost first, ending with the top
+// level of the program.
+func (p *Pause) Frames() []interpret.Frame {
+	if p.frames == nil {
+		p.frames = p.debugger.interp.Frames()
+	}
+	return p.frames
+}
+
+// FrameLine returns the line each frame is paused at: the current line for the
+// innermost frame and the line of the call for the others.
+func (p *Pause) FrameLine(frame int) int {
+	if frame == 0 {
+		return p.Line
+	}
+	return p.Frames()[frame-1].Call.Line
+}
+
+// Scope is an environment in a frame's chain.
+type Scope struct {
+	Env    *interpret.Env
+	Global bool // the environment of builtins
+}
+
+// Scopes returns the environments a frame can see, innermost first.
+func (p *Pause) Scopes(frame int) []Scope {
+	var scopes []Scope
+	for env := p.Frames()[frame].Env; env != nil; env = env.Enclosing() {
+		scopes = append(scopes, Scope{Env: env, Global: env == p.debugger.interp.Globals()})
+	}
+	return scopes
+}
+
+// Eval evaluates an expression in a frame.
+func (p *Pause) Eval(src string, frame int) (interface{}, error) {
+	var errs bytes.Buffer
+	tracker := errtrack.NewWithOutput(&errs)
+	e := parse.New(tracker, scan.New(tracker, src).Tokens()).Expression()
+	if tracker.HadError() {
+		return nil, errors.New(strings.TrimSpace(errs.String()))
+	}
+	return p.debugger.interp.EvalIn(p.Frames()[frame].Env, e)
+}
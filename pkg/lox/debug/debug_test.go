@@ -0,0 +1,232 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+)
+
+const program = `fn add(a, b) {
+  var sum = a + b;
+  return sum;
+}
+var x = add(1, 2);
+{
+  var y = add(x, 3);
+  print y;
+}
+print "done";`
+
+// debug runs program with a debugger that pauses on entry, and returns the
+// output of the program and of the handler.
+func debug(t *testing.T, handler func(p *Pause, out *bytes.Buffer) Action) (string, string, error) {
+	t.Helper()
+	return debugSource(t, program, handler)
+}
+
+// debugSource is debug for another program.
+func debugSource(t *testing.T, src string, handler func(p *Pause, out *bytes.Buffer) Action) (string, string, error) {
+	t.Helper()
+	tracker := errtrack.NewWithOutput(ioutil.Discard)
+	parser := parse.New(tracker, scan.New(tracker, src).Tokens())
+	stmts := parser.AST()
+	if tracker.HadError() {
+		t.Fatal("program does not parse")
+	}
+
+	var stdout, out bytes.Buffer
+	interp := interpret.New(tracker, interpret.Options{Stdout: &stdout, Deterministic: true})
+	d := New(interp, parser, func(p *Pause) Action { return handler(p, &out) }, true)
+	err := d.Run(context.Background(), stmts)
+	return stdout.String(), out.String(), err
+}
+
+func TestStepping(t *testing.T) {
+	table := map[string]struct {
+		actions []Action
+		want    []int // the lines paused at
+	}{
+		"step in": {
+			actions: []Action{StepIn, StepIn, StepIn, StepIn, StepIn, StepIn, StepIn, StepIn, StepIn, StepIn},
+			want:    []int{1, 5, 2, 3, 7, 2, 3, 8, 10},
+		},
+		"step over": {
+			actions: []Action{StepOver, StepOver, StepOver, StepOver, StepOver},
+			want:    []int{1, 5, 7, 8, 10},
+		},
+		"step out": {
+			actions: []Action{StepIn, StepIn, StepOut, StepOver},
+			want:    []int{1, 5, 2, 7, 8},
+		},
+	}
+
+	for name, test := range table {
+		t.Run(name, func(t *testing.T) {
+			var got []int
+			actions := test.actions
+			stdout, _, err := debug(t, func(p *Pause, _ *bytes.Buffer) Action {
+				got = append(got, p.Line)
+				if len(actions) == 0 {
+					return Continue
+				}
+				action := actions[0]
+				actions = actions[1:]
+				return action
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, test.want); diff != "" {
+				t.Errorf("incorrect pauses (-got,+want): %s", diff)
+			}
+			if stdout != "6\ndone\n" {
+				t.Errorf("got output %q", stdout)
+			}
+		})
+	}
+}
+
+func TestBreakpointEachCall(t *testing.T) {
+	const src = `fn f(x) {
+  return x * 10;
+}
+print f(1) + f(2);
+print map([3, 4], fn (x) { return x + 1; });`
+
+	var got []string
+	stdout, _, err := debugSource(t, src, func(p *Pause, _ *bytes.Buffer) Action {
+		got = append(got, fmt.Sprintf("%s@%d", p.Reason, p.Line))
+		if p.Reason == Entry {
+			p.debugger.SetBreakpoint(2)
+			p.debugger.SetBreakpoint(5)
+		}
+		return Continue
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"entry@1", "breakpoint@2", "breakpoint@2", "breakpoint@5", "breakpoint@5", "breakpoint@5"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect pauses (-got,+want): %s", diff)
+	}
+	if stdout != "30\n[4, 5]\n" {
+		t.Errorf("got output %q", stdout)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	var got []string
+	_, _, err := debug(t, func(p *Pause, _ *bytes.Buffer) Action {
+		got = append(got, fmt.Sprintf("%s@%d", p.Reason, p.Line))
+		if p.Reason == Entry {
+			p.debugger.Interrupt()
+		}
+		return Continue
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, []string{"entry@1", "interrupted@5"}); diff != "" {
+		t.Errorf("incorrect pauses (-got,+want): %s", diff)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var frames []string
+	var sum, outer interface{}
+	var evalErr error
+	_, _, err := debug(t, func(p *Pause, _ *bytes.Buffer) Action {
+		if p.Reason == Entry {
+			p.debugger.SetBreakpoint(3)
+			return Continue
+		}
+		for j, frame := range p.Frames() {
+			frames = append(frames, FrameName(frame)+"@"+Show(p.FrameLine(j)))
+		}
+		sum, _ = p.Eval("sum * 10", 0)
+		outer, _ = p.Eval("add(2, 2)", 1)
+		_, evalErr = p.Eval("nope", 0)
+		return Stop
+	})
+
+	if err != ErrStopped {
+		t.Errorf("got error %v, want %v", err, ErrStopped)
+	}
+	if diff := cmp.Diff(frames, []string{"add@3", "<script>@5"}); diff != "" {
+		t.Errorf("incorrect frames (-got,+want): %s", diff)
+	}
+	if sum != int64(30) {
+		t.Errorf("got sum * 10 = %v, want 30", sum)
+	}
+	if outer != int64(4) {
+		t.Errorf("got add(2, 2) = %v, want 4", outer)
+	}
+	if evalErr == nil || !strings.Contains(evalErr.Error(), "Undefined variable") {
+		t.Errorf("got error %v evaluating an undefined name", evalErr)
+	}
+}
+
+func TestConsole(t *testing.T) {
+	commands := `b 3
+c
+bt
+env
+p a + b
+f 1
+p "x is " + x
+breakpoints
+clear 3
+n
+s
+bogus
+c
+`
+	var out bytes.Buffer
+	tracker := errtrack.NewWithOutput(ioutil.Discard)
+	parser := parse.New(tracker, scan.New(tracker, program).Tokens())
+	stmts := parser.AST()
+	var stdout bytes.Buffer
+	interp := interpret.New(tracker, interpret.Options{Stdout: &stdout, Deterministic: true})
+	console := NewConsole(program, strings.NewReader(commands), &out)
+	if err := New(interp, parser, console.Handle, true).Run(context.Background(), stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `entry at line 1
+   1  fn add(a, b) {
+(debug) breakpoint at line 3
+(debug) breakpoint at line 3
+   3    return sum;
+(debug) * 0  add at line 3
+  1  <script> at line 5
+(debug) scope:
+  a = 1
+  b = 2
+  sum = 3
+scope:
+  add = <fn add>
+globals: 45 builtins
+(debug) 3
+(debug)    5  var x = add(1, 2);
+(debug) error: [line 1:11] at "x": Undefined variable: "x".
+(debug) line 3
+(debug) (debug) step at line 7
+   7    var y = add(x, 3);
+(debug) step at line 2
+   2    var sum = a + b;
+(debug) unknown command "bogus"; try help
+(debug) `
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("incorrect transcript (-got,+want): %s", diff)
+	}
+}
@@ -3,6 +3,7 @@ package errtrack
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
@@ -28,19 +29,27 @@ func (e LoxError) Unwrap() error {
 // Tracker tracks errors that may happen deep in the call stack.
 type Tracker struct {
 	hadError bool
+	errors   []LoxError
 	output   io.Writer
 }
 
+// New creates a tracker that reports errors to standard error.
 func New() *Tracker {
+	return NewWithOutput(os.Stderr)
+}
+
+// NewWithOutput creates a tracker that reports errors to w.
+func NewWithOutput(w io.Writer) *Tracker {
 	return &Tracker{
 		hadError: false,
-		output:   os.Stdout,
+		output:   w,
 	}
 }
 
 // Report logs an error to output and makes a note there was an error.
 func (t *Tracker) Report(err LoxError) {
 	t.hadError = true
+	t.errors = append(t.errors, err)
 	fmt.Fprintf(t.output, "%s\n", err.Error())
 }
 
@@ -55,9 +64,26 @@ func (t *Tracker) HadError() bool {
 	return t.hadError
 }
 
+// Errors returns the errors reported since the tracker was made or Reset, for
+// tools that show them somewhere other than the output.
+func (t *Tracker) Errors() []LoxError {
+	return t.errors
+}
+
+// Mute stops the tracker from reporting or noting errors until the returned
+// function is called, for errors that are handled another way.
+func (t *Tracker) Mute() (unmute func()) {
+	output, hadError, n := t.output, t.hadError, len(t.errors)
+	t.output = ioutil.Discard
+	return func() {
+		t.output, t.hadError, t.errors = output, hadError, t.errors[:n]
+	}
+}
+
 // Reset clears any errors. HadError returns false after a Reset.
 func (t *Tracker) Reset() {
 	t.hadError = false
+	t.errors = nil
 }
 
 // CatchFatal stops any calls to Tracker.Fatal from escaping a function. Must be
@@ -2,6 +2,9 @@ package expr
 
 // note that Type here is qualified as expr.Type, the expression of a type
 
+// Lambda bodies hold stmt.Type values. They cannot be typed as such because
+// the stmt package imports this one.
+
 //go:generate go run github.com/spencer-p/craftinginterpreters/cmd/genexpr
 /// import github.com/spencer-p/craftinginterpreters/pkg/lox/tok
 /// Binary: Left Type, Right Type, Op tok.Token
@@ -10,3 +13,14 @@ package expr
 /// Unary: Op tok.Token, Right Type
 /// Variable: Name tok.Token
 /// Assign: Name tok.Token, Value Type
+/// Get: Object Type, Name tok.Token
+/// Call: Callee Type, Paren tok.Token, Args []Type
+/// Lambda: Keyword tok.Token, Params []tok.Token, Body []interface{}
+/// Ternary: Cond Type, Then Type, Else Type
+/// Compound: Target Type, Op tok.Token, Value Type
+/// Increment: Target Type, Op tok.Token, Prefix bool
+/// Interpolation: Parts []Type
+/// List: Bracket tok.Token, Elements []Type
+/// Index: Object Type, Bracket tok.Token, Index Type
+/// SetIndex: Object Type, Bracket tok.Token, Index Type, Value Type
+/// Map: Brace tok.Token, Keys []Type, Values []Type
@@ -15,6 +15,17 @@ type Visitor interface {
 	VisitUnary(*Unary) interface{}
 	VisitVariable(*Variable) interface{}
 	VisitAssign(*Assign) interface{}
+	VisitGet(*Get) interface{}
+	VisitCall(*Call) interface{}
+	VisitLambda(*Lambda) interface{}
+	VisitTernary(*Ternary) interface{}
+	VisitCompound(*Compound) interface{}
+	VisitIncrement(*Increment) interface{}
+	VisitInterpolation(*Interpolation) interface{}
+	VisitList(*List) interface{}
+	VisitIndex(*Index) interface{}
+	VisitSetIndex(*SetIndex) interface{}
+	VisitMap(*Map) interface{}
 }
 
 type Binary struct {
@@ -69,3 +80,130 @@ func (e *Assign) Accept(v Visitor) interface{} {
 	return v.VisitAssign(e)
 }
 
+type Get struct {
+	Object Type
+	Name tok.Token
+}
+
+func (e *Get) Accept(v Visitor) interface{} {
+	return v.VisitGet(e)
+}
+
+type Call struct {
+	Callee Type
+	Paren tok.Token
+	Args []Type
+}
+
+func (e *Call) Accept(v Visitor) interface{} {
+	return v.VisitCall(e)
+}
+
+type Lambda struct {
+	Keyword tok.Token
+	Params []tok.Token
+	Body []interface{}
+}
+
+func (e *Lambda) Accept(v Visitor) interface{} {
+	return v.VisitLambda(e)
+}
+
+type Ternary struct {
+	Cond Type
+	Then Type
+	Else Type
+}
+
+func (e *Ternary) Accept(v Visitor) interface{} {
+	return v.VisitTernary(e)
+}
+
+type Compound struct {
+	Target Type
+	Op tok.Token
+	Value Type
+}
+
+func (e *Compound) Accept(v Visitor) interface{} {
+	return v.VisitCompound(e)
+}
+
+type Increment struct {
+	Target Type
+	Op tok.Token
+	Prefix bool
+}
+
+func (e *Increment) Accept(v Visitor) interface{} {
+	return v.VisitIncrement(e)
+}
+
+type Interpolation struct {
+	Parts []Type
+}
+
+func (e *Interpolation) Accept(v Visitor) interface{} {
+	return v.VisitInterpolation(e)
+}
+
+type List struct {
+	Bracket tok.Token
+	Elements []Type
+}
+
+func (e *List) Accept(v Visitor) interface{} {
+	return v.VisitList(e)
+}
+
+type Index struct {
+	Object Type
+	Bracket tok.Token
+	Index Type
+}
+
+func (e *Index) Accept(v Visitor) interface{} {
+	return v.VisitIndex(e)
+}
+
+type SetIndex struct {
+	Object Type
+	Bracket tok.Token
+	Index Type
+	Value Type
+}
+
+func (e *SetIndex) Accept(v Visitor) interface{} {
+	return v.VisitSetIndex(e)
+}
+
+type Map struct {
+	Brace tok.Token
+	Keys []Type
+	Values []Type
+}
+
+func (e *Map) Accept(v Visitor) interface{} {
+	return v.VisitMap(e)
+}
+
+// Kinds makes an empty node of each type, by name.
+var Kinds = map[string]func() Type{
+	"Binary": func() Type { return &Binary{} },
+	"Grouping": func() Type { return &Grouping{} },
+	"Literal": func() Type { return &Literal{} },
+	"Unary": func() Type { return &Unary{} },
+	"Variable": func() Type { return &Variable{} },
+	"Assign": func() Type { return &Assign{} },
+	"Get": func() Type { return &Get{} },
+	"Call": func() Type { return &Call{} },
+	"Lambda": func() Type { return &Lambda{} },
+	"Ternary": func() Type { return &Ternary{} },
+	"Compound": func() Type { return &Compound{} },
+	"Increment": func() Type { return &Increment{} },
+	"Interpolation": func() Type { return &Interpolation{} },
+	"List": func() Type { return &List{} },
+	"Index": func() Type { return &Index{} },
+	"SetIndex": func() Type { return &SetIndex{} },
+	"Map": func() Type { return &Map{} },
+}
@@ -0,0 +1,396 @@
+// Package format prints Lox source in a canonical style, like gofmt does for
+// Go.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/prettyprint"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+const (
+	indentation = "  "
+	maxWidth    = 80 // lists and maps wider than this are split over lines
+)
+
+// Source formats a Lox program. Statements go on their own lines, blocks are
+// indented, and at most one blank line is kept between statements.
+//
+// Comments between statements keep their place. A comment at the end of a
+// statement's last line stays there, and other comments inside a statement
+// move to the lines after it. Formatting is idempotent, and the result parses
+// to the same program as src.
+func Source(src []byte) ([]byte, error) {
+	var errs bytes.Buffer
+	tracker := errtrack.NewWithOutput(&errs)
+
+	scanner := scan.New(tracker, string(src))
+	toks := scanner.Tokens()
+	if tracker.HadError() {
+		return nil, errors.New(strings.TrimSpace(errs.String()))
+	}
+	parser := parse.New(tracker, toks)
+	stmts := parser.AST()
+	if tracker.HadError() {
+		return nil, errors.New(strings.TrimSpace(errs.String()))
+	}
+
+	p := &printer{
+		parser:   parser,
+		comments: scanner.Comments(),
+		used:     make([]bool, len(scanner.Comments())),
+		out:      new(bytes.Buffer),
+	}
+	p.stmts(stmts, tok.Token{}, toks[len(toks)-1])
+	return p.out.Bytes(), nil
+}
+
+type printer struct {
+	parser   *parse.Parser // for spans
+	comments []tok.Token
+	used     []bool // comments already printed
+	out      *bytes.Buffer
+	indent   int
+	column   int // column at the start of out, when it is a nested render
+}
+
+// before reports whether a is before b in the source.
+func before(a, b tok.Token) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Char < b.Char
+}
+
+// take marks and returns the comments strictly between after and until that
+// have not been printed.
+func (p *printer) take(after, until tok.Token) []tok.Token {
+	var taken []tok.Token
+	for j, c := range p.comments {
+		if !p.used[j] && before(after, c) && before(c, until) {
+			p.used[j] = true
+			taken = append(taken, c)
+		}
+	}
+	return taken
+}
+
+// trailing takes the comment after end on the same line, if there is one
+// before until.
+func (p *printer) trailing(end, until tok.Token) (tok.Token, bool) {
+	for j, c := range p.comments {
+		if !p.used[j] && c.Line == end.Line && before(end, c) && before(c, until) {
+			p.used[j] = true
+			return c, true
+		}
+	}
+	return tok.Token{}, false
+}
+
+func (p *printer) span(node interface{}) parse.Span {
+	span, _ := p.parser.Span(node)
+	return span
+}
+
+func (p *printer) write(s ...string) {
+	for _, part := range s {
+		p.out.WriteString(part)
+	}
+}
+
+func (p *printer) writeIndent() {
+	p.write(strings.Repeat(indentation, p.indent))
+}
+
+// col returns the column the next write will start at.
+func (p *printer) col() int {
+	b := p.out.Bytes()
+	if nl := bytes.LastIndexByte(b, '\n'); nl >= 0 {
+		return len(b) - nl - 1
+	}
+	return p.column + len(b)
+}
+
+// render prints into a string as if starting at the given indent and column.
+func (p *printer) render(indent, column int, f func()) string {
+	out, oldIndent, oldColumn := p.out, p.indent, p.column
+	p.out, p.indent, p.column = new(bytes.Buffer), indent, column
+	f()
+	s := p.out.String()
+	p.out, p.indent, p.column = out, oldIndent, oldColumn
+	return s
+}
+
+// stmts prints statements on their own lines, with the comments between open
+// and close.
+func (p *printer) stmts(stmts []stmt.Type, open, close tok.Token) {
+	printed, last := false, open.Line
+	line := func(srcLine int) {
+		if printed && srcLine > last+1 {
+			p.write("\n")
+		}
+		printed, last = true, srcLine
+		p.writeIndent()
+	}
+
+	prev := open
+	for j, s := range stmts {
+		span := p.span(s)
+		next := close
+		if j+1 < len(stmts) {
+			next = p.span(stmts[j+1]).Start
+		}
+
+		for _, c := range p.take(prev, span.Start) {
+			line(c.Line)
+			p.write(c.Lexeme, "\n")
+		}
+		line(span.Start.Line)
+		p.stmt(s, span)
+		if c, ok := p.trailing(span.End, next); ok {
+			p.write(" ", c.Lexeme)
+		}
+		p.write("\n")
+		for _, c := range p.take(span.Start, span.End) {
+			p.writeIndent()
+			p.write(c.Lexeme, "\n")
+		}
+		last, prev = span.End.Line, span.End
+	}
+
+	for _, c := range p.take(prev, close) {
+		line(c.Line)
+		p.write(c.Lexeme, "\n")
+	}
+}
+
+// block prints braces around statements, with the comments between open and
+// close.
+func (p *printer) block(stmts []stmt.Type, open, close tok.Token) {
+	if len(stmts) == 0 && !p.hasComments(open, close) {
+		p.write("{}")
+		return
+	}
+	p.write("{\n")
+	p.indent++
+	p.stmts(stmts, open, close)
+	p.indent--
+	p.writeIndent()
+	p.write("}")
+}
+
+func (p *printer) hasComments(after, until tok.Token) bool {
+	for j, c := range p.comments {
+		if !p.used[j] && before(after, c) && before(c, until) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) stmt(s stmt.Type, span parse.Span) {
+	switch s := s.(type) {
+	case *stmt.Block:
+		p.block(s.Statements, span.Start, span.End)
+	case *stmt.Expression:
+		p.expr(s.Expr)
+		p.write(";")
+	case *stmt.Print:
+		p.write("print ")
+		p.expr(s.Expr)
+		p.write(";")
+	case *stmt.Var:
+		p.write("var ", s.Name.Lexeme)
+		if s.Initializer != nil {
+			p.write(" = ")
+			p.expr(s.Initializer)
+		}
+		p.write(";")
+	case *stmt.Import:
+		if s.Names == nil {
+			p.write("import ", s.Path.Lexeme, " as ", s.Alias.Lexeme, ";")
+		} else {
+			p.write("from ", s.Path.Lexeme, " import ", names(s.Names), ";")
+		}
+	case *stmt.Export:
+		p.write("export ")
+		p.stmt(s.Decl, span)
+	case *stmt.Function:
+		p.write("fn ", s.Name.Lexeme, "(", names(s.Params), ") ")
+		p.block(s.Body, span.Start, span.End)
+	case *stmt.Return:
+		p.write("return")
+		if s.Value != nil {
+			p.write(" ")
+			p.expr(s.Value)
+		}
+		p.write(";")
+	default:
+		panic(fmt.Sprintf("format: unknown statement %T", s))
+	}
+}
+
+func names(toks []tok.Token) string {
+	parts := make([]string, len(toks))
+	for j := range toks {
+		parts[j] = toks[j].Lexeme
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (p *printer) expr(e expr.Type) {
+	switch e := e.(type) {
+	case *expr.Binary:
+		p.expr(e.Left)
+		p.write(" ", e.Op.Lexeme, " ")
+		p.expr(e.Right)
+	case *expr.Grouping:
+		p.write("(")
+		p.expr(e.Expr)
+		p.write(")")
+	case *expr.Literal:
+		p.write(prettyprint.Literal(e.Value))
+	case *expr.Unary:
+		right := p.render(p.indent, p.col()+1, func() { p.expr(e.Right) })
+		p.write(e.Op.Lexeme)
+		if e.Op.Typ == tok.MINUS && strings.HasPrefix(right, "-") {
+			p.write(" ") // not a decrement
+		}
+		p.write(right)
+	case *expr.Variable:
+		p.write(e.Name.Lexeme)
+	case *expr.Assign:
+		p.write(e.Name.Lexeme, " = ")
+		p.expr(e.Value)
+	case *expr.Get:
+		p.expr(e.Object)
+		p.write(".", e.Name.Lexeme)
+	case *expr.Call:
+		p.expr(e.Callee)
+		p.write("(")
+		for j, arg := range e.Args {
+			if j > 0 {
+				p.write(", ")
+			}
+			p.expr(arg)
+		}
+		p.write(")")
+	case *expr.Lambda:
+		p.lambda(e)
+	case *expr.Ternary:
+		p.expr(e.Cond)
+		p.write(" ? ")
+		p.expr(e.Then)
+		p.write(" : ")
+		p.expr(e.Else)
+	case *expr.Compound:
+		p.expr(e.Target)
+		p.write(" ", e.Op.Lexeme, " ")
+		p.expr(e.Value)
+	case *expr.Increment:
+		if e.Prefix {
+			p.write(e.Op.Lexeme)
+		}
+		p.expr(e.Target)
+		if !e.Prefix {
+			p.write(e.Op.Lexeme)
+		}
+	case *expr.Interpolation:
+		p.write(`"`)
+		for j, part := range e.Parts {
+			if j%2 == 0 {
+				p.write(part.(*expr.Literal).Value.(string))
+			} else {
+				p.write("${")
+				p.expr(part)
+				p.write("}")
+			}
+		}
+		p.write(`"`)
+	case *expr.List:
+		items := make([]func(), len(e.Elements))
+		for j := range e.Elements {
+			el := e.Elements[j]
+			items[j] = func() { p.expr(el) }
+		}
+		p.items("[", "]", items)
+	case *expr.Map:
+		items := make([]func(), len(e.Keys))
+		for j := range e.Keys {
+			key, value := e.Keys[j], e.Values[j]
+			items[j] = func() {
+				p.expr(key)
+				p.write(": ")
+				p.expr(value)
+			}
+		}
+		p.items("{", "}", items)
+	case *expr.Index:
+		p.expr(e.Object)
+		p.write("[")
+		p.expr(e.Index)
+		p.write("]")
+	case *expr.SetIndex:
+		p.expr(e.Object)
+		p.write("[")
+		p.expr(e.Index)
+		p.write("] = ")
+		p.expr(e.Value)
+	default:
+		panic(fmt.Sprintf("format: unknown expression %T", e))
+	}
+}
+
+// lambda prints arrow functions on one line and fn expressions with a block.
+func (p *printer) lambda(e *expr.Lambda) {
+	if e.Keyword.Typ == tok.ARROW {
+		if len(e.Params) == 1 {
+			p.write(e.Params[0].Lexeme)
+		} else {
+			p.write("(", names(e.Params), ")")
+		}
+		p.write(" => ")
+		p.expr(e.Body[0].(*stmt.Return).Value)
+		return
+	}
+
+	body := make([]stmt.Type, len(e.Body))
+	for j := range e.Body {
+		body[j] = e.Body[j].(stmt.Type)
+	}
+	span := p.span(e)
+	p.write("fn (", names(e.Params), ") ")
+	p.block(body, span.Start, span.End)
+}
+
+// items prints the elements of a list or map on one line if they fit, or else
+// one per line with a trailing comma.
+func (p *printer) items(open, close string, items []func()) {
+	rendered := make([]string, len(items))
+	width, multiline := p.col()+len(open)+len(close), false
+	for j, item := range items {
+		rendered[j] = p.render(p.indent+1, (p.indent+1)*len(indentation), item)
+		width += len(rendered[j])
+		if j > 0 {
+			width += len(", ")
+		}
+		multiline = multiline || strings.Contains(rendered[j], "\n")
+	}
+
+	if !multiline && width <= maxWidth {
+		p.write(open, strings.Join(rendered, ", "), close)
+		return
+	}
+	p.write(open, "\n")
+	for _, item := range rendered {
+		p.write(strings.Repeat(indentation, p.indent+1), item, ",\n")
+	}
+	p.writeIndent()
+	p.write(close)
+}
@@ -0,0 +1,113 @@
+package format
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// TestGolden formats each testdata/*.input and compares it to the .golden file
+// beside it.
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		input := input
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			src, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(input, ".input") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Source(src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(string(got), string(want)); diff != "" {
+				t.Errorf("incorrect output (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+// TestCorpus checks that formatting every Lox file in the repository is
+// idempotent and does not change what it parses to.
+func TestCorpus(t *testing.T) {
+	var paths []string
+	for _, pattern := range []string{"testdata/*.input", "testdata/*.golden", "../testdata/*.lox"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+
+	for _, path := range paths {
+		path := path
+		t.Run(path, func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			once, err := Source(src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			twice, err := Source(once)
+			if err != nil {
+				t.Fatalf("cannot format formatted source: %v", err)
+			}
+
+			if diff := cmp.Diff(string(twice), string(once)); diff != "" {
+				t.Errorf("formatting is not idempotent (-twice,+once): %s", diff)
+			}
+			if diff := cmp.Diff(parseString(t, string(once)), parseString(t, string(src)), sameAST...); diff != "" {
+				t.Errorf("formatting changed the program (-formatted,+original): %s", diff)
+			}
+		})
+	}
+}
+
+// sameAST compares programs without regard to where their tokens are.
+var sameAST = []cmp.Option{
+	cmp.Comparer(func(a, b tok.Token) bool {
+		// fun is written as fn.
+		return a.Typ == b.Typ && (a.Typ == tok.FN || a.Lexeme == b.Lexeme)
+	}),
+	cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 }),
+	cmp.Comparer(func(a, b *big.Rat) bool { return a.Cmp(b) == 0 }),
+}
+
+func parseString(t *testing.T, in string) []stmt.Type {
+	t.Helper()
+	fake := errtrack.NewFake()
+	stmts := parse.New(fake.Tracker, scan.New(fake.Tracker, in).Tokens()).AST()
+	if fake.Tracker.HadError() {
+		t.Fatalf("could not parse %q: %s", in, fake.Errors())
+	}
+	return stmts
+}
+
+func TestErrors(t *testing.T) {
+	for _, in := range []string{`print "unterminated;`, `var = 1;`, `print 1`} {
+		if _, err := Source([]byte(in)); err == nil {
+			t.Errorf("formatted %q without error", in)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package interpret
+
+import (
+	"fmt"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Callable is any Lox value that can be called. Paren is the token closing the
+// call's arguments, for reporting errors.
+type Callable interface {
+	Arity() int // negative if any number of arguments is accepted
+	Call(i *Interpreter, paren tok.Token, args []interface{}) interface{}
+}
+
+// Function is a Lox function along with the environment it closes over.
+// Lambdas are functions without a name.
+type Function struct {
+	name    string
+	params  []tok.Token
+	body    []stmt.Type
+	closure *Env
+	module  *Module // where the function is written, for its imports
+}
+
+// returnValue carries a return statement's value up to the enclosing call.
+type returnValue struct {
+	value interface{}
+}
+
+func (f *Function) Arity() int {
+	return len(f.params)
+}
+
+func (f *Function) Call(i *Interpreter, paren tok.Token, args []interface{}) (result interface{}) {
+	i.allocN(int64(len(args)), slotSize)
+	i.alloc(objectSize)
+	env := NewEnv(i.tracker, f.closure)
+	for j, param := range f.params {
+		env.Define(param.Lexeme, args[j])
+	}
+
+	if i.hooks != nil {
+		defer i.pushFrame(f.name, paren)()
+	}
+	prevModule := i.module
+	i.module = f.module
+	defer func() {
+		i.module = prevModule
+		if r := recover(); r != nil {
+			ret, ok := r.(returnValue)
+			if !ok {
+				panic(r)
+			}
+			result = ret.value
+		}
+	}()
+
+	i.executeBlock(f.body, env)
+	return nil
+}
+
+func (f *Function) String() string {
+	if f.name == "" {
+		return "<fn>"
+	}
+	return fmt.Sprintf("<fn %s>", f.name)
+}
+
+// Native is a function implemented in Go.
+type Native struct {
+	name  string
+	arity int
+	fn    func(i *Interpreter, paren tok.Token, args []interface{}) interface{}
+}
+
+func (n *Native) Arity() int {
+	return n.arity
+}
+
+func (n *Native) Call(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return n.fn(i, paren, args)
+}
+
+func (n *Native) String() string {
+	return fmt.Sprintf("<native fn %s>", n.name)
+}
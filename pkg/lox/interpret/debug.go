@@ -0,0 +1,68 @@
+package interpret
+
+import (
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Frame is a call in progress, or the top level of the script.
+type Frame struct {
+	Name string    // the function's name; empty for lambdas and the top level
+	Call tok.Token // the parenthesis closing the call; zero at the top level
+	Env  *Env      // the environment the frame is running in
+}
+
+// Frames returns the calls in progress, innermost first, ending with the top
+// level. It may only be called from a hook.
+func (i *Interpreter) Frames() []Frame {
+	frames := make([]Frame, 0, len(i.frames)+1)
+	env := i.env
+	for j := len(i.frames) - 1; j >= 0; j-- {
+		frames = append(frames, Frame{Name: i.frames[j].name, Call: i.frames[j].call, Env: env})
+		env = i.frames[j].callerEnv
+	}
+	return append(frames, Frame{Env: env})
+}
+
+// Globals returns the environment holding the builtins, which encloses the
+// top level of every module.
+func (i *Interpreter) Globals() *Env {
+	return i.globals
+}
+
+// EvalIn evaluates e in env, as a debugger does with a paused frame. Errors
+// are returned instead of reported, and hooks are not called.
+func (i *Interpreter) EvalIn(env *Env, e expr.Type) (val interface{}, err error) {
+	prevEnv, prevHooks := i.env, i.hooks
+	unmute := i.tracker.Mute()
+	i.env, i.hooks = env, nil
+	defer func() {
+		i.env, i.hooks = prevEnv, prevHooks
+		unmute()
+		if r := recover(); r != nil {
+			loxErr, ok := r.(errtrack.LoxError)
+			if !ok {
+				panic(r)
+			}
+			err = loxErr
+		}
+	}()
+	return i.eval(e), nil
+}
+
+// frame is a call tracked for Frames.
+type frame struct {
+	name      string
+	call      tok.Token
+	callerEnv *Env
+}
+
+// pushFrame notes that a call is starting, and returns a function to call when
+// it ends.
+func (i *Interpreter) pushFrame(name string, call tok.Token) (pop func()) {
+	i.frames = append(i.frames, frame{name: name, call: call, callerEnv: i.env})
+	return func() {
+		i.frames = i.frames[:len(i.frames)-1]
+	}
+}
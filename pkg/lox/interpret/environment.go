@@ -2,6 +2,7 @@ package interpret
 
 import (
 	"errors"
+	"sort"
 
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
@@ -62,7 +63,34 @@ func (e *Env) Assign(name tok.Token, val interface{}) {
 
 	if _, ok := e.table[name.Lexeme]; !ok {
 		e.enclosing.Assign(name, val)
+		return
 	}
 
 	e.table[name.Lexeme] = val
 }
+
+// Enclosing returns the environment e is nested in, or nil if e is the
+// outermost.
+func (e *Env) Enclosing() *Env {
+	if e.enclosing == nil || e.enclosing.table == nil {
+		return nil
+	}
+	return e.enclosing
+}
+
+// Names returns the names defined directly in e, in sorted order.
+func (e *Env) Names() []string {
+	names := make([]string, 0, len(e.table))
+	for name := range e.table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the value of a name defined directly in e. The value is
+// Uninitialized for variables declared without a value.
+func (e *Env) Lookup(name string) (interface{}, bool) {
+	val, ok := e.table[name]
+	return val, ok
+}
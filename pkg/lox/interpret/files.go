@@ -0,0 +1,61 @@
+package interpret
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrorReadOnly = errors.New("file system is read-only")
+
+// FileSystem is the capability scripts use to reach files. Names are slash
+// separated and relative to the root of the file system, as with fs.FS.
+type FileSystem interface {
+	fs.FS
+	WriteFile(name string, data []byte) error
+}
+
+// ReadOnlyFS lets scripts read, but not write, the files in fsys.
+func ReadOnlyFS(fsys fs.FS) FileSystem {
+	return readOnlyFS{fsys}
+}
+
+type readOnlyFS struct {
+	fs.FS
+}
+
+func (readOnlyFS) WriteFile(name string, data []byte) error {
+	return &fs.PathError{Op: "write", Path: name, Err: ErrorReadOnly}
+}
+
+// DirFS lets scripts read and write the files under a host directory, and
+// nothing outside of it. As with os.DirFS, symbolic links inside the directory
+// are followed.
+func DirFS(dir string) FileSystem {
+	return dirFS{FS: os.DirFS(dir), dir: dir}
+}
+
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+func (d dirFS) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.WriteFile(filepath.Join(d.dir, filepath.FromSlash(name)), data, 0666)
+}
+
+// cleanPath converts a path written in a script to a name in a FileSystem. The
+// root and the current directory are the same place.
+func cleanPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
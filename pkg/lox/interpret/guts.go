@@ -3,6 +3,8 @@ package interpret
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
@@ -13,6 +15,18 @@ var (
 	ErrorNotANumber = errors.New("Operand must be number.")
 	ErrorNotAString = errors.New("Operand must be string.")
 	ErrorUnknownOp  = errors.New("Unknown operand.")
+
+	ErrorNoProperties = errors.New("Only modules have properties.")
+	ErrorNotCallable  = errors.New("Can only call functions.")
+
+	// compoundOps maps compound assignment operators to their arithmetic.
+	compoundOps = map[tok.TokenType]tok.TokenType{
+		tok.PLUS_EQUAL:    tok.PLUS,
+		tok.MINUS_EQUAL:   tok.MINUS,
+		tok.STAR_EQUAL:    tok.STAR,
+		tok.SLASH_EQUAL:   tok.SLASH,
+		tok.PERCENT_EQUAL: tok.PERCENT,
+	}
 )
 
 func truthy(value interface{}) bool {
@@ -41,15 +55,22 @@ func equal(a, b interface{}) (result bool) {
 		return actual == b.(bool)
 	case string:
 		return actual == b.(string)
-	case float64:
-		return actual == b.(float64)
+	case int64, *big.Int, *big.Rat, float64:
+		if !isNumber(b) {
+			return false
+		}
+		c, ok := compare(actual, b)
+		return ok && c == 0
+	case Time:
+		return actual.t.Equal(b.(Time).t)
 	default:
-		return false
+		// Everything else, such as functions, is equal only to itself.
+		return a == b
 	}
 }
 
 func (i *Interpreter) checkNumber(op tok.Token, value interface{}) {
-	if _, ok := value.(float64); !ok {
+	if !isNumber(value) {
 		i.tracker.Fatal(errtrack.LoxError{
 			Message: ErrorNotANumber,
 			Token:   op,
@@ -63,14 +84,88 @@ func (i *Interpreter) checkNumbers(op tok.Token, values ...interface{}) {
 	}
 }
 
+// repr is like Stringify but quotes strings, so they can be told apart from
+// other values inside containers. A container inside itself is printed as
+// [...] or {...}. It keeps its own stack rather than recursing, so that values
+// nested arbitrarily deeply cannot overflow Go's.
+func repr(value interface{}) string {
+	var b strings.Builder
+	seen := make(map[interface{}]bool) // containers being printed
+	var stack []reprFrame
+
+	// open prints value, or starts printing it if it is a container.
+	open := func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			b.WriteString(strconv.Quote(v))
+		case *List:
+			if seen[v] {
+				b.WriteString("[...]")
+				return
+			}
+			seen[v] = true
+			b.WriteByte('[')
+			stack = append(stack, reprFrame{container: v, n: len(v.Elements)})
+		case *Map:
+			if seen[v] {
+				b.WriteString("{...}")
+				return
+			}
+			seen[v] = true
+			b.WriteByte('{')
+			stack = append(stack, reprFrame{container: v, n: len(v.entries)})
+		default:
+			b.WriteString(Stringify(v))
+		}
+	}
+
+	open(value)
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next == top.n {
+			if _, ok := top.container.(*List); ok {
+				b.WriteByte(']')
+			} else {
+				b.WriteByte('}')
+			}
+			delete(seen, top.container)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if top.next > 0 {
+			b.WriteString(", ")
+		}
+		j := top.next
+		top.next++
+		switch c := top.container.(type) {
+		case *List:
+			open(c.Elements[j])
+		case *Map:
+			// Keys are never containers, so they are printed right away.
+			open(c.entries[j].key)
+			b.WriteString(": ")
+			open(c.entries[j].value)
+		}
+	}
+	return b.String()
+}
+
+// reprFrame is a container repr has started printing.
+type reprFrame struct {
+	container interface{} // a *List or *Map
+	next, n   int         // the next element to print, of n
+}
+
 func Stringify(result interface{}) string {
-	if result == nil {
+	switch actual := result.(type) {
+	case nil:
 		return "nil"
+	case float64:
+		return formatFloat(actual)
+	case *big.Rat:
+		return formatRat(actual)
+	default:
+		return fmt.Sprintf("%v", result)
 	}
-	s := fmt.Sprintf("%v", result)
-	if _, ok := result.(float64); ok && strings.HasSuffix(s, ".0") {
-		// Remove .0 only from floats
-		s = s[:len(s)-2]
-	}
-	return s
 }
@@ -0,0 +1,48 @@
+package interpret
+
+import (
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Hooks observe a script as it runs, for tools like debuggers, profilers,
+// coverage and audit logs. They are called in the goroutine running the
+// script, which waits for them to return.
+type Hooks interface {
+	// OnStatement is called before each statement runs, including those of
+	// imported modules.
+	OnStatement(st stmt.Type)
+
+	// OnCall is called before a function or native is called, once its
+	// arguments are checked. Paren is the token closing the arguments.
+	OnCall(fn Callable, paren tok.Token, args []interface{})
+
+	// OnReturn is called when a call returns. Calls that end in an error do
+	// not return.
+	OnReturn(fn Callable, paren tok.Token, result interface{})
+
+	// OnError is called with the runtime error that stopped a script, after
+	// it is reported.
+	OnError(err errtrack.LoxError)
+
+	// OnPrint is called with the text of each print statement, without its
+	// newline, before it is written.
+	OnPrint(text string)
+}
+
+// NopHooks ignores everything. Embed it to implement only some of Hooks.
+type NopHooks struct{}
+
+func (NopHooks) OnStatement(stmt.Type)                     {}
+func (NopHooks) OnCall(Callable, tok.Token, []interface{}) {}
+func (NopHooks) OnReturn(Callable, tok.Token, interface{}) {}
+func (NopHooks) OnError(errtrack.LoxError)                 {}
+func (NopHooks) OnPrint(string)                            {}
+
+// SetHooks sets the hooks to call as scripts run, or removes them if hooks is
+// nil. Without hooks, the interpreter only checks that there are none. Frames
+// are only tracked while there are hooks.
+func (i *Interpreter) SetHooks(hooks Hooks) {
+	i.hooks = hooks
+}
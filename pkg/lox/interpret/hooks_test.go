@@ -0,0 +1,82 @@
+package interpret
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// recorder logs what a script does.
+type recorder struct {
+	statements int
+	log        []string
+}
+
+func (r *recorder) OnStatement(st stmt.Type) {
+	r.statements++
+}
+
+func (r *recorder) OnCall(fn Callable, paren tok.Token, args []interface{}) {
+	r.log = append(r.log, fmt.Sprintf("call %v %v on line %d", fn, args, paren.Line))
+}
+
+func (r *recorder) OnReturn(fn Callable, paren tok.Token, result interface{}) {
+	r.log = append(r.log, fmt.Sprintf("return %v from %v", result, fn))
+}
+
+func (r *recorder) OnError(err errtrack.LoxError) {
+	r.log = append(r.log, fmt.Sprintf("error %v", err.Message))
+}
+
+func (r *recorder) OnPrint(text string) {
+	r.log = append(r.log, "print "+text)
+}
+
+func TestHooks(t *testing.T) {
+	const src = `fn double(n) { return n * 2; }
+print double(len("abc"));
+fn fail() { return nope; }
+fail();`
+
+	fake := errtrack.NewFake()
+	var out bytes.Buffer
+	i := New(fake.Tracker, Options{Stdout: &out})
+	var r recorder
+	i.SetHooks(&r)
+	if err := i.InterpretContext(context.Background(), parseString(t, fake.Tracker, src)); err == nil {
+		t.Fatal("script did not fail")
+	}
+
+	want := []string{
+		"call <native fn len> [abc] on line 2",
+		"return 3 from <native fn len>",
+		"call <fn double> [3] on line 2",
+		"return 6 from <fn double>",
+		"print 6",
+		"call <fn fail> [] on line 4",
+		`error Undefined variable: "nope".`,
+	}
+	if diff := cmp.Diff(r.log, want); diff != "" {
+		t.Errorf("incorrect log (-got,+want): %s", diff)
+	}
+	if r.statements != 6 {
+		t.Errorf("saw %d statements, want 6", r.statements)
+	}
+	if out.String() != "6\n" {
+		t.Errorf("got output %q", out.String())
+	}
+
+	i.SetHooks(nil)
+	fake.Tracker.Reset()
+	i.Interpret(parseString(t, fake.Tracker, `print double(1);`))
+	if len(r.log) != len(want) {
+		t.Errorf("hooks were called after they were removed: %v", r.log[len(want):])
+	}
+}
@@ -1,9 +1,15 @@
 package interpret
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
@@ -15,84 +21,245 @@ import (
 type Interpreter struct {
 	tracker *errtrack.Tracker
 	out     io.Writer
+	buf     *bufio.Writer // wraps out if output is buffered
+	errOut  io.Writer
+	in      *bufio.Reader
+	files   FileSystem // nil unless scripts may access files
+	clock   func() time.Time
+	rand    *rand.Rand
 	env     *Env
+	globals *Env
+
+	loader  ModuleLoader
+	module  *Module            // module currently executing
+	modules map[string]*Module // every module imported so far
+
+	ctx       context.Context
+	steps     int64 // steps taken by the current InterpretContext
+	maxSteps  int64
+	depth     int // number of calls in progress
+	maxDepth  int
+	allocated int64 // bytes allocated by the current InterpretContext
+	maxMemory int64
+	site      tok.Token // most recent call or concatenation, for limit errors
+
+	hooks  Hooks   // nil unless set
+	frames []frame // calls in progress, tracked while there are hooks
+
+	deterministic bool
 }
 
 // Verify it satisfies the visitor types
 var _ expr.Visitor = &Interpreter{}
 var _ stmt.Visitor = &Interpreter{}
 
-func New(tracker *errtrack.Tracker) *Interpreter {
-	return &Interpreter{
+// Options configure a new Interpreter. The zero value uses the process's
+// standard streams.
+type Options struct {
+	Stdin  io.Reader // read by input and readLine
+	Stdout io.Writer // written by print
+	Stderr io.Writer // written by eprint
+
+	// Files are the files scripts may access, or nil for none.
+	Files FileSystem
+
+	// Buffered holds back output until it is flushed, which happens at the end
+	// of Interpret, before reading input and before writing to Stderr.
+	Buffered bool
+
+	// MaxSteps limits the number of statements and expressions each call to
+	// Interpret evaluates. Zero means no limit.
+	MaxSteps int64
+
+	// MaxCallDepth limits how deeply calls may nest. Zero means
+	// DefaultMaxCallDepth.
+	MaxCallDepth int
+
+	// MaxMemory limits the bytes of strings and objects each call to Interpret
+	// allocates. Memory is not given back when values become unreachable.
+	// Zero means no limit.
+	MaxMemory int64
+
+	// Deterministic makes a script's output depend only on its source and
+	// Stdin, for golden tests and replays. The clock is fixed at
+	// DeterministicTime, the random module is seeded with Seed, files and
+	// imports are disabled and Stdin is empty unless given. Maps always
+	// iterate in insertion order.
+	Deterministic bool
+	Seed          int64
+}
+
+// DeterministicTime is the time of the clock in deterministic mode.
+var DeterministicTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// New creates an interpreter. Errors are reported through the tracker, which
+// has its own output.
+func New(tracker *errtrack.Tracker, opts Options) *Interpreter {
+	clock, seed := time.Now, time.Now().UnixNano()
+	if opts.Deterministic {
+		clock = func() time.Time { return DeterministicTime }
+		seed = opts.Seed
+		opts.Files = nil
+		if opts.Stdin == nil {
+			opts.Stdin = strings.NewReader("")
+		}
+	}
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	if opts.MaxCallDepth == 0 {
+		opts.MaxCallDepth = DefaultMaxCallDepth
+	}
+	if opts.MaxMemory == 0 {
+		opts.MaxMemory = math.MaxInt64
+	}
+
+	globals := NewEnv(tracker, nil)
+	main := newModule(tracker, "", globals, nil)
+	main.loaded = true
+
+	i := &Interpreter{
 		tracker: tracker,
-		out:     os.Stdout,
-		env:     NewEnv(tracker, nil),
+		out:     opts.Stdout,
+		errOut:  opts.Stderr,
+		in:      bufio.NewReader(opts.Stdin),
+		files:   opts.Files,
+		clock:   clock,
+		rand:    rand.New(rand.NewSource(seed)),
+		env:     main.env,
+		globals: globals,
+		module:  main,
+		modules: make(map[string]*Module),
+
+		ctx:       context.Background(),
+		maxSteps:  opts.MaxSteps,
+		maxDepth:  opts.MaxCallDepth,
+		maxMemory: opts.MaxMemory,
+
+		deterministic: opts.Deterministic,
+	}
+	i.defineNatives(stringNatives)
+	i.defineNatives(listNatives)
+	i.defineNatives(mapNatives)
+	i.defineNatives(clockNatives)
+	i.defineNatives(ioNatives)
+	i.defineModule("math", mathNatives, mathConstants)
+	i.defineModule("time", timeNatives, timeConstants)
+	i.defineModule("json", jsonNatives, nil)
+	i.defineModule("re", reNatives, nil)
+	i.defineModule("random", randomNatives, nil)
+
+	if opts.Buffered {
+		i.buf = bufio.NewWriter(i.out)
+		i.out = i.buf
 	}
+	return i
 }
 
+// Interpret executes statements, reporting any error through the tracker.
 func (i *Interpreter) Interpret(stmts []stmt.Type) {
-	defer i.tracker.CatchFatal()
-	for _, st := range stmts {
-		i.execute(st)
+	i.InterpretContext(context.Background(), stmts)
+}
+
+// Flush writes any buffered output.
+func (i *Interpreter) Flush() error {
+	if i.buf == nil {
+		return nil
 	}
+	return i.buf.Flush()
 }
 
+// SetOutput replaces the writer print writes to. Output to w is not buffered.
 func (i *Interpreter) SetOutput(w io.Writer) {
-	i.out = w
+	i.Flush()
+	i.out, i.buf = w, nil
+}
+
+// SetInput replaces the reader that input and readLine take lines from.
+func (i *Interpreter) SetInput(r io.Reader) {
+	i.in = bufio.NewReader(r)
+}
+
+// SetFiles lets scripts access the files in fsys. Scripts cannot access any
+// files unless this is set, and never in deterministic mode.
+func (i *Interpreter) SetFiles(fsys FileSystem) {
+	i.files = fsys
+}
+
+// SetClock replaces the source of the current time, such as for tests.
+func (i *Interpreter) SetClock(now func() time.Time) {
+	i.clock = now
 }
 
 func (i *Interpreter) execute(st stmt.Type) {
+	i.step()
+	if i.hooks != nil {
+		i.hooks.OnStatement(st)
+	}
 	st.Accept(i)
 }
 
 func (i *Interpreter) eval(e expr.Type) interface{} {
+	i.step()
 	return e.Accept(i)
 }
 
 func (i *Interpreter) VisitBinary(e *expr.Binary) interface{} {
 	left := i.eval(e.Left)
 	right := i.eval(e.Right)
+	return i.binary(e.Op, left, right)
+}
 
-	switch e.Op.Typ {
-	case tok.MINUS:
-		i.checkNumbers(e.Op, right, left)
-		return left.(float64) - right.(float64)
-	case tok.SLASH:
-		i.checkNumbers(e.Op, right, left)
-		return left.(float64) / right.(float64)
-	case tok.STAR:
-		i.checkNumbers(e.Op, right, left)
-		return left.(float64) * right.(float64)
+// binary applies a binary operator to its evaluated operands.
+func (i *Interpreter) binary(op tok.Token, left, right interface{}) interface{} {
+	switch op.Typ {
+	case tok.MINUS, tok.SLASH, tok.STAR, tok.PERCENT, tok.TILDE_SLASH, tok.STAR_STAR:
+		i.checkNumbers(op, right, left)
+		return i.arith(op, left, right)
+	case tok.AMPERSAND, tok.PIPE, tok.CARET, tok.LESS_LESS, tok.GREATER_GREATER:
+		return i.bitwise(op, left, right)
 	case tok.PLUS:
-		if leftActual, ok := left.(float64); ok {
-			if rightActual, ok := right.(float64); ok {
-				return leftActual + rightActual
+		if isNumber(left) {
+			if isNumber(right) {
+				return i.arith(op, left, right)
 			}
 			i.tracker.Fatal(errtrack.LoxError{
 				Message: ErrorNotANumber,
-				Token:   e.Op,
+				Token:   op,
 			})
 		} else if leftActual, ok := left.(string); ok {
 			if rightActual, ok := right.(string); ok {
+				i.site = op
+				i.alloc(int64(len(leftActual) + len(rightActual)))
 				return leftActual + rightActual
 			}
 			i.tracker.Fatal(errtrack.LoxError{
 				Message: ErrorNotAString,
-				Token:   e.Op,
+				Token:   op,
 			})
 		}
-	case tok.GREATER:
-		i.checkNumbers(e.Op, right, left)
-		return left.(float64) > right.(float64)
-	case tok.GREATER_EQUAL:
-		i.checkNumbers(e.Op, right, left)
-		return left.(float64) >= right.(float64)
-	case tok.LESS:
-		i.checkNumbers(e.Op, right, left)
-		return left.(float64) < right.(float64)
-	case tok.LESS_EQUAL:
-		i.checkNumbers(e.Op, right, left)
-		return left.(float64) <= right.(float64)
+	case tok.GREATER, tok.GREATER_EQUAL, tok.LESS, tok.LESS_EQUAL:
+		i.checkNumbers(op, right, left)
+		c, ok := compare(left, right)
+		if !ok {
+			return false
+		}
+		switch op.Typ {
+		case tok.GREATER:
+			return c > 0
+		case tok.GREATER_EQUAL:
+			return c >= 0
+		case tok.LESS:
+			return c < 0
+		default:
+			return c <= 0
+		}
 	case tok.BANG_EQUAL:
 		return !equal(left, right)
 	case tok.EQUAL_EQUAL:
@@ -100,12 +267,12 @@ func (i *Interpreter) VisitBinary(e *expr.Binary) interface{} {
 	default:
 		i.tracker.Fatal(errtrack.LoxError{
 			Message: ErrorUnknownOp,
-			Token:   e.Op,
+			Token:   op,
 		})
 	}
 	i.tracker.Fatal(errtrack.LoxError{
 		Message: ErrorNotANumber,
-		Token:   e.Op,
+		Token:   op,
 	})
 	return nil // unreachable
 }
@@ -123,9 +290,11 @@ func (i *Interpreter) VisitUnary(e *expr.Unary) interface{} {
 	switch e.Op.Typ {
 	case tok.MINUS:
 		i.checkNumbers(e.Op, right)
-		right = -1 * right.(float64)
+		right = i.negate(e.Op, right)
 	case tok.BANG:
 		right = !truthy(right)
+	case tok.TILDE:
+		right = i.complement(e.Op, right)
 	}
 	return right
 }
@@ -136,8 +305,11 @@ func (i *Interpreter) VisitExpression(st *stmt.Expression) interface{} {
 }
 
 func (i *Interpreter) VisitPrint(st *stmt.Print) interface{} {
-	val := i.eval(st.Expr)
-	fmt.Fprintln(i.out, Stringify(val))
+	text := Stringify(i.eval(st.Expr))
+	if i.hooks != nil {
+		i.hooks.OnPrint(text)
+	}
+	fmt.Fprintln(i.out, text)
 	return nil
 }
 
@@ -151,6 +323,7 @@ func (i *Interpreter) VisitVar(st *stmt.Var) interface{} {
 		val = i.eval(st.Initializer)
 	}
 
+	i.alloc(slotSize)
 	i.env.Define(st.Name.Lexeme, val)
 	return nil
 }
@@ -161,7 +334,190 @@ func (i *Interpreter) VisitAssign(e *expr.Assign) interface{} {
 	return val
 }
 
+func (i *Interpreter) VisitGet(e *expr.Get) interface{} {
+	obj := i.eval(e.Object)
+	if mod, ok := obj.(*Module); ok {
+		return mod.Get(e.Name)
+	}
+
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: ErrorNoProperties,
+		Token:   e.Name,
+	})
+	return nil // unreachable
+}
+
+func (i *Interpreter) VisitImport(st *stmt.Import) interface{} {
+	mod := i.importModule(st.Path)
+	if st.Names == nil {
+		i.env.Define(st.Alias.Lexeme, mod)
+		return nil
+	}
+
+	for _, name := range st.Names {
+		i.env.Define(name.Lexeme, mod.Get(name))
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitExport(st *stmt.Export) interface{} {
+	var name tok.Token
+	switch decl := st.Decl.(type) {
+	case *stmt.Var:
+		name = decl.Name
+	case *stmt.Function:
+		name = decl.Name
+	}
+
+	if i.env != i.module.env {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorExportScope,
+			Token:   name,
+		})
+	}
+
+	i.execute(st.Decl)
+	i.module.exports[name.Lexeme] = true
+	return nil
+}
+
+func (i *Interpreter) VisitCall(e *expr.Call) interface{} {
+	callee := i.eval(e.Callee)
+
+	args := make([]interface{}, len(e.Args))
+	for j, arg := range e.Args {
+		args[j] = i.eval(arg)
+	}
+	return i.call(e.Paren, callee, args)
+}
+
+// call calls callee after checking it is callable with args. Natives use it to
+// call functions they are given.
+func (i *Interpreter) call(paren tok.Token, callee interface{}, args []interface{}) interface{} {
+	i.site = paren
+	defer i.enter()()
+
+	fn, ok := callee.(Callable)
+	if !ok {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorNotCallable,
+			Token:   paren,
+		})
+	}
+
+	if fn.Arity() >= 0 && fn.Arity() != len(args) {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Expected %d arguments but got %d.", fn.Arity(), len(args)),
+			Token:   paren,
+		})
+	}
+
+	if i.hooks != nil {
+		i.hooks.OnCall(fn, paren, args)
+		result := fn.Call(i, paren, args)
+		i.hooks.OnReturn(fn, paren, result)
+		return result
+	}
+	return fn.Call(i, paren, args)
+}
+
+func (i *Interpreter) VisitLambda(e *expr.Lambda) interface{} {
+	body := make([]stmt.Type, len(e.Body))
+	for j := range e.Body {
+		body[j] = e.Body[j].(stmt.Type)
+	}
+
+	i.alloc(objectSize)
+	return &Function{
+		params:  e.Params,
+		body:    body,
+		closure: i.env,
+		module:  i.module,
+	}
+}
+
+func (i *Interpreter) VisitFunction(st *stmt.Function) interface{} {
+	i.alloc(objectSize + slotSize)
+	i.env.Define(st.Name.Lexeme, &Function{
+		name:    st.Name.Lexeme,
+		params:  st.Params,
+		body:    st.Body,
+		closure: i.env,
+		module:  i.module,
+	})
+	return nil
+}
+
+func (i *Interpreter) VisitReturn(st *stmt.Return) interface{} {
+	var val interface{}
+	if st.Value != nil {
+		val = i.eval(st.Value)
+	}
+	panic(returnValue{val})
+}
+
+func (i *Interpreter) VisitTernary(e *expr.Ternary) interface{} {
+	if truthy(i.eval(e.Cond)) {
+		return i.eval(e.Then)
+	}
+	return i.eval(e.Else)
+}
+
+func (i *Interpreter) VisitCompound(e *expr.Compound) interface{} {
+	op := e.Op
+	op.Typ = compoundOps[op.Typ]
+
+	load, store := i.reference(e.Target)
+	val := i.binary(op, load(), i.eval(e.Value))
+	store(val)
+	return val
+}
+
+func (i *Interpreter) VisitIncrement(e *expr.Increment) interface{} {
+	load, store := i.reference(e.Target)
+	old := load()
+	i.checkNumber(e.Op, old)
+
+	op := e.Op
+	op.Typ = tok.PLUS
+	if e.Op.Typ == tok.MINUS_MINUS {
+		op.Typ = tok.MINUS
+	}
+	val := i.arith(op, old, int64(1))
+	store(val)
+
+	if e.Prefix {
+		return val
+	}
+	return old
+}
+
+// reference evaluates the parts of an expression the parser accepted as
+// assignable, returning functions to load and store its value. This way an
+// index is evaluated only once by compound assignments.
+func (i *Interpreter) reference(target expr.Type) (load func() interface{}, store func(interface{})) {
+	switch t := target.(type) {
+	case *expr.Index:
+		load, store = i.element(t.Bracket, i.eval(t.Object), i.eval(t.Index))
+	default:
+		env, name := i.env, target.(*expr.Variable).Name
+		load = func() interface{} { return env.Get(name) }
+		store = func(val interface{}) { env.Assign(name, val) }
+	}
+	return load, store
+}
+
+func (i *Interpreter) VisitInterpolation(e *expr.Interpolation) interface{} {
+	var b strings.Builder
+	for _, part := range e.Parts {
+		b.WriteString(Stringify(i.eval(part)))
+	}
+	i.alloc(int64(b.Len()))
+	return b.String()
+}
+
 func (i *Interpreter) VisitBlock(st *stmt.Block) interface{} {
+	i.alloc(objectSize)
 	i.executeBlock(st.Statements, NewEnv(i.tracker, i.env))
 	return nil
 }
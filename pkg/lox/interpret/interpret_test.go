@@ -2,7 +2,11 @@ package interpret
 
 import (
 	"bytes"
+	"math/big"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
@@ -40,6 +44,141 @@ func TestInterpret(t *testing.T) {
 		"assign to outer":    {in: "var x = 2; {x = 1;} print x;", want: "1"},
 		"assign to descoped": {in: "{var x = 1;} x = 2;", wanterr: true},
 		"use uninitialized":  {in: "var x; print x;", wanterr: true},
+		"function":           {in: "fn f(a, b) { return a - b; } print f(3, 1);", want: "2"},
+		"implicit nil":       {in: "fn f() {} print f();", want: "nil"},
+		"fn expression":      {in: "var f = fn (a) { return -a; }; print f(1);", want: "-1"},
+		"arrow":              {in: "var f = (a, b) => a * b; print f(2, 3);", want: "6"},
+		"bare arrow":         {in: "var f = a => a + 1; print f(1);", want: "2"},
+		"nullary arrow":      {in: "print (() => 1)();", want: "1"},
+		"lambda argument":    {in: "fn apply(f, x) { return f(x); } print apply(x => x * 10, 4);", want: "40"},
+		"closure":            {in: "fn mk() { var n = 0; return () => n = n + 1; } var c = mk(); c(); print c();", want: "2"},
+		"closure assigns":    {in: "var n = 0; var inc = () => n = n + 1; inc(); inc(); print n;", want: "2"},
+		"fn equality":        {in: "fn f() {} var g = f; print f == g;", want: "true"},
+		"call non-function":  {in: "var x = 1; x();", wanterr: true},
+		"wrong arity":        {in: "fn f(a) {} f(1, 2);", wanterr: true},
+		"print function":     {in: "fn f() {} print f;", want: "<fn f>"},
+		"print lambda":       {in: "print () => 1;", want: "<fn>"},
+		"modulo":             {in: "print 7 % 3;", want: "1"},
+		"int division":       {in: "print -7 ~/ 2;", want: "-3"},
+		"power":              {in: "print 2 ** 10;", want: "1024"},
+		"power right assoc":  {in: "print 2 ** 3 ** 2;", want: "512"},
+		"power over unary":   {in: "print -2 ** 2;", want: "-4"},
+		"increment power":    {in: "var x = 2; print ++x ** 2; print x-- ** 2; print x;", want: "9\n9\n2"},
+		"ternary":            {in: "print 1 > 2 ? \"yes\" : \"no\";", want: "no"},
+		"nested ternary":     {in: "print false ? 1 : nil ? 2 : 3;", want: "3"},
+		"ternary lazy":       {in: "print true ? 1 : undefined;", want: "1"},
+		"plus equal":         {in: "var x = 1; x += 2; print x;", want: "3"},
+		"compound result":    {in: "var x = 10; print x /= 4;", want: "2.5"},
+		"string plus equal":  {in: "var s = \"a\"; s += \"b\"; print s;", want: "ab"},
+		"modulo equal":       {in: "var x = 10; x %= 4; print x;", want: "2"},
+		"compound bad type":  {in: "var x = true; x -= 1;", wanterr: true},
+		"postfix increment":  {in: "var x = 1; print x++; print x;", want: "1\n2"},
+		"prefix increment":   {in: "var x = 1; print ++x; print x;", want: "2\n2"},
+		"postfix decrement":  {in: "var x = 1; print x--; print x;", want: "1\n0"},
+		"prefix decrement":   {in: "var x = 1; print --x;", want: "0"},
+		"increment closure":  {in: "var x = 0; var f = () => ++x; f(); f(); print x;", want: "2"},
+		"increment string":   {in: "var s = \"a\"; s++;", wanterr: true},
+		"bit and":            {in: "print 6 & 3;", want: "2"},
+		"bit or":             {in: "print 6 | 3;", want: "7"},
+		"bit xor":            {in: "print 6 ^ 3;", want: "5"},
+		"bit not":            {in: "print ~5;", want: "-6"},
+		"shift left":         {in: "print 1 << 10;", want: "1024"},
+		"shift right":        {in: "print -16 >> 2;", want: "-4"},
+		"bit precedence":     {in: "print 1 | 6 & 3 == 3;", want: "true"},
+		"largest integer":    {in: "print (9007199254740991 ^ 0) == 9007199254740991;", want: "true"},
+		"fractional operand": {in: "print 1.5 & 1;", wanterr: true},
+		"too many bits":      {in: "print 9007199254740992.0 | 0;", wanterr: true},
+		"64 bit integers":    {in: "print 9007199254740993 | 0;", want: "9007199254740993"},
+		"shift overflow":     {in: "print 1 << 63;", want: "9223372036854775808"},
+		"negative shift":     {in: "print 1 >> -1;", wanterr: true},
+		"bit not string":     {in: "print ~\"a\";", wanterr: true},
+		"float literal":      {in: "print 1.0;", want: "1.0"},
+		"int literal":        {in: "print 1;", want: "1"},
+		"int arithmetic":     {in: "print 2 * 3 - 1;", want: "5"},
+		"promotion":          {in: "print 2 * 1.5;", want: "3.0"},
+		"division is float":  {in: "print 6 / 3;", want: "2.0"},
+		"int division int":   {in: "print 7 ~/ 2;", want: "3"},
+		"float int division": {in: "print 7.0 ~/ 2;", want: "3.0"},
+		"int modulo zero":    {in: "print 1 % 0;", wanterr: true},
+		"int division zero":  {in: "print 1 ~/ 0;", wanterr: true},
+		"float div zero":     {in: "print 1 / 0;", want: "inf"},
+		"int power":          {in: "print 3 ** 3;", want: "27"},
+		"negative exponent":  {in: "print 2 ** -2;", want: "0.25"},
+		"add overflow":       {in: "print 9223372036854775807 + 1;", want: "9223372036854775808"},
+		"sub overflow":       {in: "print -9223372036854775807 - 2;", want: "-9223372036854775809"},
+		"mul overflow":       {in: "print 4611686018427387904 * 2;", want: "9223372036854775808"},
+		"power overflow":     {in: "print 2 ** 63;", want: "9223372036854775808"},
+		"increment overflow": {in: "var x = 9223372036854775807; x++; print x;", want: "9223372036854775808"},
+		"negate overflow":    {in: "print -(-9223372036854775807 - 1);", want: "9223372036854775808"},
+		"big narrows":        {in: "print (2 ** 64 - 2 ** 64) + 1 == 1;", want: "true"},
+		"big literal":        {in: "print 99999999999999999999 + 1;", want: "100000000000000000000"},
+		"big division":       {in: "print 2 ** 100 ~/ 2 ** 98;", want: "4"},
+		"big modulo":         {in: "print (2 ** 100 + 3) % 2 ** 64;", want: "3"},
+		"big comparison":     {in: "print 2 ** 100 > 9223372036854775807;", want: "true"},
+		"big float mix":      {in: "print 2 ** 64 * 0.5;", want: "9223372036854776000.0"},
+		"big bitwise":        {in: "print (1 << 64 | 1) & 3;", want: "1"},
+		"big shift right":    {in: "print (1 << 64) >> 63;", want: "2"},
+		"big complement":     {in: "print ~(1 << 64);", want: "-18446744073709551617"},
+		"huge power":         {in: "print 2 ** 9223372036854775807;", wanterr: true},
+		"decimal literal":    {in: "print 1.10d;", want: "1.1d"},
+		"decimal integer":    {in: "print 3d;", want: "3d"},
+		"decimal add":        {in: "print 0.1d + 0.2d;", want: "0.3d"},
+		"decimal exact":      {in: "print 0.1d + 0.2d == 0.3d;", want: "true"},
+		"decimal int mix":    {in: "print 1.10d * 3;", want: "3.3d"},
+		"decimal float mix":  {in: "print 1.5d + 0.5;", want: "2.0"},
+		"decimal divide":     {in: "print 10d / 4;", want: "2.5d"},
+		"rational":           {in: "print 1 / 3d;", want: "1/3d"},
+		"rational roundtrip": {in: "print 1 / 3d * 3 == 1;", want: "true"},
+		"decimal int div":    {in: "print 7.5d ~/ 2;", want: "3"},
+		"decimal modulo":     {in: "print -7.5d % 2;", want: "-1.5d"},
+		"decimal power":      {in: "print 1.5d ** 2;", want: "2.25d"},
+		"decimal neg power":  {in: "print 2d ** -2;", want: "0.25d"},
+		"decimal comparison": {in: "print 0.1d < 0.2d;", want: "true"},
+		"decimal zero div":   {in: "print 1d / 0;", wanterr: true},
+		"decimal negate":     {in: "print -1.25d;", want: "-1.25d"},
+		"interpolation":      {in: `var x = 1; print "x is ${x + 1}!";`, want: "x is 2!"},
+		"interp only":        {in: `print "${nil}";`, want: "nil"},
+		"interp many":        {in: `print "${1}, ${2.0}, ${true}";`, want: "1, 2.0, true"},
+		"interp nested":      {in: `var x = "y"; print "a ${"b ${x}"} c";`, want: "a b y c"},
+		"interp braces":      {in: `print "${(fn () { return 1; })()}";`, want: "1"},
+		"interp lone dollar": {in: `print "$5 {x}";`, want: "$5 {x}"},
+		"interp error":       {in: `print "${-true}";`, wanterr: true},
+		"list literal":       {in: `print [1, "two", [3.0], nil];`, want: `[1, "two", [3.0], nil]`},
+		"empty list":         {in: `print [];`, want: `[]`},
+		"trailing comma":     {in: `print [1, 2,];`, want: `[1, 2]`},
+		"index":              {in: `var xs = [1, 2]; print xs[1];`, want: "2"},
+		"index assign":       {in: `var xs = [1, 2]; xs[0] = 3; print xs;`, want: "[3, 2]"},
+		"index compound":     {in: `var xs = [1, 2]; xs[1] *= 5; print xs;`, want: "[1, 10]"},
+		"index increment":    {in: `var xs = [1]; print xs[0]++; print ++xs[0];`, want: "1\n3"},
+		"index evaluated once": {
+			in:   `var i = 0; var xs = [0, 0]; xs[i++] += 1; print xs; print i;`,
+			want: "[1, 0]\n1",
+		},
+		"index out of range": {in: `var xs = [1]; print xs[1];`, wanterr: true},
+		"negative index":     {in: `var xs = [1]; print xs[-1];`, wanterr: true},
+		"float index":        {in: `var xs = [1]; print xs[0.0];`, wanterr: true},
+		"index non-list":     {in: `var x = 1; print x[0];`, wanterr: true},
+		"list identity":      {in: `var xs = []; print xs == xs; print [] == [];`, want: "true\nfalse"},
+		"list in itself":     {in: `var xs = [1]; xs[0] = xs; print xs; print "${[xs, xs]}";`, want: "[[...]]\n[[[...]], [[...]]]"},
+		"map literal":        {in: `print {"a": 1, 2: [3], nil: true};`, want: `{"a": 1, 2: [3], nil: true}`},
+		"empty map":          {in: `print {};`, want: `{}`},
+		"map index":          {in: `var m = {"a": 1}; print m["a"];`, want: "1"},
+		"map missing key":    {in: `var m = {"a": 1}; print m["b"];`, wanterr: true},
+		"map insert order":   {in: `var m = {"b": 1}; m["a"] = 2; m["b"] = 3; print m;`, want: `{"b": 3, "a": 2}`},
+		"map number keys":    {in: `var m = {1: "one"}; print m[1.0]; print m[1d];`, want: "one\none"},
+		"map compound":       {in: `var m = {"n": 1}; m["n"] += 2; m["n"]++; print m;`, want: `{"n": 4}`},
+		"map list key":       {in: `var m = {}; m[[1]] = 1;`, wanterr: true},
+		"map nan key":        {in: `print {0.0 / 0.0: 1};`, wanterr: true},
+		"map functions":      {in: `var m = {"a": 1, "b": 2}; print keys(m); print values(m); print has(m, "a"); print remove(m, "a"); print len(m);`, want: "[\"a\", \"b\"]\n[1, 2]\ntrue\n1\n1"},
+		"map remove missing": {in: `print remove({}, "a");`, want: "nil"},
+		"map block":          {in: `{ print {"a": 1}["a"]; }`, want: "1"},
+		"map in itself":      {in: `var m = {}; m["x"] = m; print m; print "${m}";`, want: "{\"x\": {...}}\n{\"x\": {...}}"},
+		"map and list cycle": {in: `var m = {}; var xs = [m, m]; m["xs"] = xs; print xs;`, want: "[{\"xs\": [...]}, {\"xs\": [...]}]"},
+		"increment float":    {in: "var x = 1.5; x++; print x;", want: "2.5"},
+		"mixed equality":     {in: "print 1 == 1.0;", want: "true"},
+		"mixed comparison":   {in: "print 1 < 1.5;", want: "true"},
+		"nan unordered":      {in: "var n = 0.0 / 0.0; print n == n; print n < 1;", want: "false\nfalse"},
+		"large float":        {in: "print 100000000000000000000.0 * 10;", want: "1e+21"},
 	}
 
 	for name, tc := range table {
@@ -57,8 +196,7 @@ func TestInterpret(t *testing.T) {
 				t.Fatalf(string(fake.Errors()))
 			}
 
-			interpreter := New(fake.Tracker)
-			interpreter.SetOutput(&fakeOut)
+			interpreter := New(fake.Tracker, Options{Stdout: &fakeOut})
 			interpreter.Interpret(ast)
 			if fake.Tracker.HadError() {
 				if tc.wanterr {
@@ -77,9 +215,260 @@ func TestInterpret(t *testing.T) {
 }
 
 func TestStringify(t *testing.T) {
-	want := "4"
-	got := Stringify(4.0)
-	if got != want {
-		t.Errorf("got %q, wanted %q", got, want)
+	table := []struct {
+		in   interface{}
+		want string
+	}{
+		{in: 4.0, want: "4.0"},
+		{in: int64(4), want: "4"},
+		{in: 0.1, want: "0.1"},
+		{in: -2.5, want: "-2.5"},
+		{in: 1e100, want: "1e+100"},
+		{in: 1e-7, want: "1e-07"},
+		{in: nil, want: "nil"},
+		{in: big.NewRat(11, 10), want: "1.1d"},
+		{in: big.NewRat(1, 3), want: "1/3d"},
+		{in: big.NewRat(1, 8), want: "0.125d"},
+		{in: big.NewRat(-7, 20), want: "-0.35d"},
+		{in: new(big.Int).Lsh(big.NewInt(1), 70), want: "1180591620717411303424"},
+	}
+
+	for _, tc := range table {
+		got := Stringify(tc.in)
+		if got != tc.want {
+			t.Errorf("got %q, wanted %q", got, tc.want)
+		}
+	}
+}
+
+// TestDeepValues prints values nested too deeply for a recursive printer,
+// under a small stack so that it would overflow quickly.
+func TestDeepValues(t *testing.T) {
+	defer debug.SetMaxStack(debug.SetMaxStack(16 << 20))
+
+	const in = `var xs = reduce(runes(repeat("a", 100000)), (acc, c) => [acc], []);
+var m = reduce(runes(repeat("a", 100000)), (acc, c) => {"k": acc}, {});
+print len("${xs}");
+print len("${m}");
+print "${[[m]]}" == "[[" + "${m}" + "]]";`
+	got, errs := interpretString(t, in)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected error: %q", errs)
+	}
+	if want := "200002\n700002\ntrue\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestImport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/util.lox":   {Data: []byte(`print "loading"; export var x = 1; var hidden = 2;`)},
+		"lib/nested.lox": {Data: []byte(`import "util.lox" as u; export var y = u.x + 1;`)},
+		"path/far.lox":   {Data: []byte(`export var z = "far";`)},
+		"lib/lazy.lox":   {Data: []byte(`export fn load() { import "util.lox" as u; return u.x; } export var next = fn () { return load() + 1; };`)},
+		"cycle/a.lox":    {Data: []byte(`import "b.lox" as b;`)},
+		"cycle/b.lox":    {Data: []byte(`import "a.lox" as a;`)},
+		"bad.lox":        {Data: []byte(`export var = 1;`)},
+	}
+
+	table := map[string]struct {
+		in      string
+		want    string
+		wanterr bool
+	}{
+		"import as":          {in: `import "lib/util.lox" as u; print u.x;`, want: "loading\n1"},
+		"from import":        {in: `from "lib/util.lox" import x; print x;`, want: "loading\n1"},
+		"evaluated once":     {in: `import "lib/util.lox" as u; import "lib/util.lox" as v; print v.x;`, want: "loading\n1"},
+		"relative import":    {in: `import "lib/nested.lox" as n; print n.y;`, want: "loading\n2"},
+		"import in function": {in: `import "lib/lazy.lox" as l; print l.load(); print l.next();`, want: "loading\n1\n2"},
+		"search path":        {in: `import "far.lox" as f; print f.z;`, want: "far"},
+		"module stringify":   {in: `import "path/far.lox" as f; print f;`, want: `<module "path/far.lox">`},
+		"not exported":       {in: `import "lib/util.lox" as u; print u.hidden;`, wanterr: true},
+		"not found":          {in: `import "nope.lox" as n;`, wanterr: true},
+		"cycle":              {in: `import "cycle/a.lox" as a;`, wanterr: true},
+		"module parse error": {in: `import "bad.lox" as b;`, wanterr: true},
+		"export in block":    {in: `{ export var x = 1; }`, wanterr: true},
+		"get on non-module":  {in: `var x = 1; print x.y;`, wanterr: true},
+	}
+
+	for name, tc := range table {
+		t.Run(name, func(t *testing.T) {
+			var fakeOut bytes.Buffer
+			fake := errtrack.NewFake()
+
+			toks := scan.New(fake.Tracker, tc.in).Tokens()
+			ast := parse.New(fake.Tracker, toks).AST()
+			if fake.Tracker.HadError() {
+				t.Fatalf(string(fake.Errors()))
+			}
+
+			interpreter := New(fake.Tracker, Options{Stdout: &fakeOut})
+			interpreter.SetLoader(&FSLoader{FS: fsys, SearchPath: []string{"path"}}, "main.lox")
+			interpreter.Interpret(ast)
+			if fake.Tracker.HadError() {
+				if tc.wanterr {
+					return
+				}
+				t.Fatalf("unexpected error: %q", fake.Errors())
+			} else if tc.wanterr {
+				t.Fatalf("wanted an error but got none")
+			}
+
+			if diff := cmp.Diff(fakeOut.String(), tc.want+"\n"); diff != "" {
+				t.Errorf("incorrect interpretation (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestImportRoots(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/main.lox":      {Data: []byte(``)},
+		"app/sub/local.lox": {Data: []byte(`export var x = "local";`)},
+		"lib/shared.lox":    {Data: []byte(`export var x = "shared";`)},
+		"lib/escape.lox":    {Data: []byte(`import "../secret.lox" as s;`)},
+		"secret.lox":        {Data: []byte(`export var x = "secret";`)},
+	}
+	loader := &FSLoader{FS: fsys, SearchPath: []string{"lib"}, Roots: []string{"app", "lib"}}
+
+	table := map[string]struct {
+		in      string
+		wanterr bool
+	}{
+		"subdirectory":        {in: `import "sub/local.lox" as m; print m.x;`},
+		"search path":         {in: `import "shared.lox" as m; print m.x;`},
+		"other root":          {in: `import "../lib/shared.lox" as m; print m.x;`},
+		"climb out":           {in: `import "../secret.lox" as m;`, wanterr: true},
+		"climb out of search": {in: `import "../../secret.lox" as m;`, wanterr: true},
+		"module climbs out":   {in: `import "escape.lox" as m;`, wanterr: true},
+	}
+	for name, tc := range table {
+		t.Run(name, func(t *testing.T) {
+			_, errs := interpretWith(t, tc.in, func(i *Interpreter) {
+				i.SetLoader(loader, "app/main.lox")
+			})
+			if tc.wanterr && !bytes.Contains(errs, []byte(ErrorOutsideRoots.Error())) {
+				t.Errorf("got errors %q, want %q", errs, ErrorOutsideRoots)
+			} else if !tc.wanterr && len(errs) > 0 {
+				t.Errorf("unexpected error: %q", errs)
+			}
+		})
+	}
+}
+
+func TestImportCycleMessage(t *testing.T) {
+	fake := errtrack.NewFake()
+	fsys := fstest.MapFS{
+		"a.lox": {Data: []byte(`import "b.lox" as b;`)},
+		"b.lox": {Data: []byte(`import "a.lox" as a;`)},
+	}
+
+	ast := parse.New(fake.Tracker, scan.New(fake.Tracker, `import "a.lox" as a;`).Tokens()).AST()
+	interpreter := New(fake.Tracker, Options{})
+	interpreter.SetLoader(&FSLoader{FS: fsys}, "main.lox")
+	interpreter.Interpret(ast)
+
+	want := "Import cycle: a.lox -> b.lox -> a.lox."
+	if !bytes.Contains(fake.Errors(), []byte(want)) {
+		t.Errorf("got errors %q, wanted them to contain %q", fake.Errors(), want)
+	}
+}
+
+// interpretString runs a program, returning its output and any errors.
+func interpretString(t *testing.T, in string) (string, []byte) {
+	t.Helper()
+	return interpretWith(t, in, func(*Interpreter) {})
+}
+
+// interpretWith is like interpretString but lets the caller configure the
+// interpreter first.
+func interpretWith(t *testing.T, in string, setup func(*Interpreter)) (string, []byte) {
+	t.Helper()
+	var fakeOut bytes.Buffer
+	fake := errtrack.NewFake()
+
+	toks := scan.New(fake.Tracker, in).Tokens()
+	ast := parse.New(fake.Tracker, toks).AST()
+	if fake.Tracker.HadError() {
+		t.Fatalf("could not parse %q: %s", in, fake.Errors())
+	}
+
+	interpreter := New(fake.Tracker, Options{Stdout: &fakeOut})
+	setup(interpreter)
+	interpreter.Interpret(ast)
+	return fakeOut.String(), fake.Errors()
+}
+
+// scriptTest is a script and what it should print, for runTable.
+type scriptTest struct {
+	src     string // if set, the global src; Lox strings cannot contain quotes
+	in      string
+	want    string // the output without its final newline
+	wanterr bool
+}
+
+// runTable runs each script in table as a subtest, after the setup functions
+// configure its interpreter.
+func runTable(t *testing.T, table map[string]scriptTest, setup ...func(*Interpreter)) {
+	t.Helper()
+	for name, tc := range table {
+		t.Run(name, func(t *testing.T) {
+			got, errs := interpretWith(t, tc.in, func(i *Interpreter) {
+				if tc.src != "" {
+					i.globals.Define("src", tc.src)
+				}
+				for _, fn := range setup {
+					fn(i)
+				}
+			})
+			if len(errs) > 0 {
+				if !tc.wanterr {
+					t.Errorf("unexpected error: %q", errs)
+				}
+				return
+			} else if tc.wanterr {
+				t.Fatalf("wanted an error but got none")
+			}
+
+			if diff := cmp.Diff(got, tc.want+"\n"); diff != "" {
+				t.Errorf("incorrect output (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestOutputStreams(t *testing.T) {
+	fake := errtrack.NewFake()
+	ast := parse.New(fake.Tracker, scan.New(fake.Tracker, `print 1; eprint(2); print 3;`).Tokens()).AST()
+
+	var stdout, stderr bytes.Buffer
+	New(fake.Tracker, Options{Stdout: &stdout, Stderr: &stderr}).Interpret(ast)
+	if diff := cmp.Diff(stdout.String(), "1\n3\n"); diff != "" {
+		t.Errorf("incorrect stdout (-got,+want): %s", diff)
+	}
+	if diff := cmp.Diff(stderr.String(), "2\n"); diff != "" {
+		t.Errorf("incorrect stderr (-got,+want): %s", diff)
+	}
+}
+
+func TestBufferedOutput(t *testing.T) {
+	fake := errtrack.NewFake()
+	ast := parse.New(fake.Tracker, scan.New(fake.Tracker, `print 1; eprint(2); print 3; print input(); print -"x";`).Tokens()).AST()
+
+	// Sharing a writer shows that output is flushed in order, and that the
+	// interpreter flushes at the end even after an error.
+	var out bytes.Buffer
+	interpreter := New(fake.Tracker, Options{
+		Stdin:    strings.NewReader("4\n"),
+		Stdout:   &out,
+		Stderr:   &out,
+		Buffered: true,
+	})
+	interpreter.Interpret(ast)
+	if diff := cmp.Diff(out.String(), "1\n2\n3\n4\n"); diff != "" {
+		t.Errorf("incorrect output (-got,+want): %s", diff)
+	}
+	if !fake.Tracker.HadError() {
+		t.Errorf("wanted an error but got none")
 	}
 }
@@ -0,0 +1,159 @@
+package interpret
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+)
+
+// DefaultMaxCallDepth bounds recursion when Options.MaxCallDepth is zero, well
+// before Go's own stack would overflow.
+const DefaultMaxCallDepth = 10000
+
+// checkInterval is the number of steps between checks for cancellation.
+const checkInterval = 1024
+
+// Rough sizes used to account for memory. They only need to be in proportion to
+// what Go really allocates.
+const (
+	objectSize = 64 // a list, map, function, environment or similar
+	slotSize   = 16 // one value held by a list, map or environment
+)
+
+// StepLimitError stops a script that ran for more steps than allowed.
+type StepLimitError struct {
+	Limit int64
+}
+
+func (e *StepLimitError) Error() string {
+	return fmt.Sprintf("Exceeded the limit of %d steps.", e.Limit)
+}
+
+// CallDepthError stops a script that nested calls deeper than allowed.
+type CallDepthError struct {
+	Limit int
+}
+
+func (e *CallDepthError) Error() string {
+	return fmt.Sprintf("Exceeded the maximum call depth of %d.", e.Limit)
+}
+
+// MemoryLimitError stops a script that allocated more memory than allowed.
+type MemoryLimitError struct {
+	Limit int64
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("Exceeded the memory limit of %d bytes.", e.Limit)
+}
+
+// CanceledError stops a script whose context was done. It unwraps to the
+// context's error.
+type CanceledError struct {
+	Err error
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("Execution stopped: %v.", e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// InterpretContext executes statements until they finish, fail or ctx is done.
+// Errors are reported through the tracker as usual and also returned as an
+// errtrack.LoxError. Use errors.As to find a *StepLimitError, *CallDepthError,
+// *MemoryLimitError or *CanceledError inside it. Deeply nested lists and maps
+// are no more able to overflow Go's stack than deep calls: printing them does
+// not recurse, and json.stringify stops with an error at maxJSONDepth.
+func (i *Interpreter) InterpretContext(ctx context.Context, stmts []stmt.Type) (err error) {
+	i.ctx, i.steps, i.allocated = ctx, 0, 0
+	defer i.Flush()
+	defer func() {
+		if r := recover(); r != nil {
+			loxErr, ok := r.(errtrack.LoxError)
+			if !ok {
+				panic(r)
+			}
+			err = loxErr
+			if i.hooks != nil {
+				i.hooks.OnError(loxErr)
+			}
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		i.fatalHere(&CanceledError{err})
+	}
+	for _, st := range stmts {
+		i.execute(st)
+	}
+	return nil
+}
+
+// step counts one unit of work against the budget and periodically checks
+// for cancellation.
+func (i *Interpreter) step() {
+	i.steps++
+	if i.maxSteps > 0 && i.steps > i.maxSteps {
+		i.fatalHere(&StepLimitError{i.maxSteps})
+	}
+	if i.steps%checkInterval == 0 {
+		if err := i.ctx.Err(); err != nil {
+			i.fatalHere(&CanceledError{err})
+		}
+	}
+}
+
+// Allocated returns the number of bytes the last call to Interpret accounted
+// for. It counts every string and object the script created, including those
+// that are no longer reachable.
+func (i *Interpreter) Allocated() int64 {
+	return i.allocated
+}
+
+// alloc accounts for n bytes a script is about to allocate. Wherever a result
+// may be much larger than its inputs, it is called before allocating.
+func (i *Interpreter) alloc(n int64) {
+	if n > i.maxMemory-i.allocated {
+		i.fatalHere(&MemoryLimitError{i.maxMemory})
+	}
+	i.allocated += n
+}
+
+// allocN accounts for count items of size bytes, without overflowing.
+func (i *Interpreter) allocN(count, size int64) {
+	if size > 0 && count > math.MaxInt64/size {
+		i.fatalHere(&MemoryLimitError{i.maxMemory})
+	}
+	i.alloc(count * size)
+}
+
+// allocList accounts for a list of n elements.
+func (i *Interpreter) allocList(n int) {
+	i.allocN(int64(n), slotSize)
+	i.alloc(objectSize)
+}
+
+// enter notes that a call is starting, and returns a function to call when it
+// ends.
+func (i *Interpreter) enter() (exit func()) {
+	if i.depth >= i.maxDepth {
+		i.fatalHere(&CallDepthError{i.maxDepth})
+	}
+	i.depth++
+	return func() { i.depth-- }
+}
+
+// fatalHere stops the script with an error at the most recent call or string
+// concatenation, since most nodes do not carry a token.
+func (i *Interpreter) fatalHere(err error) {
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: err,
+		Token:   i.site,
+	})
+}
@@ -0,0 +1,187 @@
+package interpret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+)
+
+const (
+	forever     = `fn f() { return f(); } f();`
+	exponential = `fn f(n) { return n == 0 ? 0 : f(n - 1) + f(n - 1); } print f(60);`
+)
+
+func parseString(t *testing.T, tracker *errtrack.Tracker, in string) []stmt.Type {
+	t.Helper()
+	ast := parse.New(tracker, scan.New(tracker, in).Tokens()).AST()
+	if tracker.HadError() {
+		t.Fatalf("could not parse %q", in)
+	}
+	return ast
+}
+
+func TestCallDepth(t *testing.T) {
+	table := map[string]struct {
+		in    string
+		depth int
+		want  int // limit reported by the error, or 0 for none
+	}{
+		"default": {in: forever, want: DefaultMaxCallDepth},
+		"within":  {in: `fn f(n) { return n == 0 ? 0 : f(n - 1); } f(9);`, depth: 10},
+		"beyond":  {in: `fn f(n) { return n == 0 ? 0 : f(n - 1); } f(10);`, depth: 10, want: 10},
+		"natives": {in: `fn f(n) { return map([n], f); } f(1);`, depth: 50, want: 50},
+		"wide":    {in: `fn f(n) { return n == 0 ? 0 : f(n - 1) + f(n - 1); } f(10);`, depth: 11},
+	}
+
+	for name, tc := range table {
+		t.Run(name, func(t *testing.T) {
+			fake := errtrack.NewFake()
+			i := New(fake.Tracker, Options{MaxCallDepth: tc.depth})
+			err := i.InterpretContext(context.Background(), parseString(t, fake.Tracker, tc.in))
+
+			var depthErr *CallDepthError
+			if tc.want == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			} else if !errors.As(err, &depthErr) {
+				t.Fatalf("wanted a CallDepthError but got %v", err)
+			}
+			if depthErr.Limit != tc.want {
+				t.Errorf("got limit %d, want %d", depthErr.Limit, tc.want)
+			}
+			if i.depth != 0 {
+				t.Errorf("depth is %d after unwinding", i.depth)
+			}
+		})
+	}
+}
+
+// TestDeepData checks that scripts cannot get past the call depth limit by
+// nesting data instead, which natives walk without making Lox calls.
+func TestDeepData(t *testing.T) {
+	defer debug.SetMaxStack(debug.SetMaxStack(16 << 20))
+	const nest = `var xs = reduce(runes(repeat("a", 200000)), (acc, c) => [acc], []);`
+
+	t.Run("print", func(t *testing.T) {
+		fake := errtrack.NewFake()
+		var out bytes.Buffer
+		err := New(fake.Tracker, Options{Stdout: &out}).InterpretContext(context.Background(), parseString(t, fake.Tracker, nest+` print xs;`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.Len() != 400003 {
+			t.Errorf("printed %d bytes, want 400003", out.Len())
+		}
+	})
+
+	t.Run("stringify", func(t *testing.T) {
+		fake := errtrack.NewFake()
+		err := New(fake.Tracker, Options{}).InterpretContext(context.Background(), parseString(t, fake.Tracker, nest+` json.stringify(xs);`))
+		if !errors.Is(err, ErrorJSONDepth) {
+			t.Fatalf("wanted ErrorJSONDepth but got %v", err)
+		}
+	})
+}
+
+func TestStepLimit(t *testing.T) {
+	fake := errtrack.NewFake()
+	i := New(fake.Tracker, Options{MaxSteps: 5000})
+
+	err := i.InterpretContext(context.Background(), parseString(t, fake.Tracker, exponential))
+	var stepErr *StepLimitError
+	if !errors.As(err, &stepErr) || stepErr.Limit != 5000 {
+		t.Fatalf("wanted a StepLimitError with limit 5000 but got %v", err)
+	}
+	if !fake.Tracker.HadError() {
+		t.Errorf("limit was not reported to the tracker")
+	}
+
+	// The budget applies to each run separately.
+	fake.Tracker.Reset()
+	if err := i.InterpretContext(context.Background(), parseString(t, fake.Tracker, `print 1;`)); err != nil {
+		t.Errorf("unexpected error on second run: %v", err)
+	}
+}
+
+func TestInterpretContext(t *testing.T) {
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		fake := errtrack.NewFake()
+		err := New(fake.Tracker, Options{}).InterpretContext(ctx, parseString(t, fake.Tracker, exponential))
+		var canceled *CanceledError
+		if !errors.As(err, &canceled) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("wanted a CanceledError for the deadline but got %v", err)
+		}
+	})
+
+	t.Run("already canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		fake := errtrack.NewFake()
+		err := New(fake.Tracker, Options{}).InterpretContext(ctx, parseString(t, fake.Tracker, `print 1;`))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("wanted context.Canceled but got %v", err)
+		}
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		fake := errtrack.NewFake()
+		err := New(fake.Tracker, Options{}).InterpretContext(context.Background(), parseString(t, fake.Tracker, `-"x";`))
+		if !errors.Is(err, ErrorNotANumber) {
+			t.Fatalf("wanted ErrorNotANumber but got %v", err)
+		}
+	})
+}
+
+func TestMemoryLimit(t *testing.T) {
+	table := map[string]struct {
+		in    string
+		limit int64
+		want  bool // whether the limit is exceeded
+	}{
+		"doubling":        {in: `fn f(s) { return f(s + s); } f("x");`, limit: 1 << 20, want: true},
+		"compound":        {in: `fn f(s) { s += s; return f(s); } f("x");`, limit: 1 << 20, want: true},
+		"interpolation":   {in: `fn f(s) { return f("${s}${s}"); } f("x");`, limit: 1 << 20, want: true},
+		"lists":           {in: `fn f(xs) { return f([xs, xs]); } f([]);`, limit: 100000, want: true},
+		"map entries":     {in: `fn f(m, n) { m[n] = n; return f(m, n + 1); } f({}, 0);`, limit: 100000, want: true},
+		"repeat":          {in: `repeat("x", 1 << 40);`, limit: 1 << 20, want: true},
+		"repeat overflow": {in: `repeat("xx", 1 << 62);`, want: true},
+		"replace":         {in: `replace(repeat("x", 1000), "x", repeat("y", 10000));`, limit: 1 << 20, want: true},
+		"regex replace":   {in: `re.replace("", repeat("x", 1000), repeat("y", 10000));`, limit: 1 << 20, want: true},
+		"json indent":     {in: `json.stringify([[[[[[1]]]]]], repeat(" ", 100000));`, limit: 1 << 20, want: true},
+		"within":          {in: `var s = repeat("x", 1000); var xs = split(s, ""); print len(xs);`, limit: 1 << 20},
+		"unlimited":       {in: `var s = repeat("x", 1 << 20); print len(s + s);`},
+	}
+
+	for name, tc := range table {
+		t.Run(name, func(t *testing.T) {
+			fake := errtrack.NewFake()
+			i := New(fake.Tracker, Options{MaxMemory: tc.limit})
+			err := i.InterpretContext(context.Background(), parseString(t, fake.Tracker, tc.in))
+
+			var memErr *MemoryLimitError
+			if got := errors.As(err, &memErr); got != tc.want {
+				t.Fatalf("got error %v, wanted a MemoryLimitError: %t", err, tc.want)
+			}
+			if tc.limit > 0 && i.Allocated() > tc.limit {
+				t.Errorf("allocated %d bytes, beyond the limit of %d", i.Allocated(), tc.limit)
+			}
+			if !tc.want && i.Allocated() == 0 {
+				t.Errorf("no allocations were accounted for")
+			}
+		})
+	}
+}
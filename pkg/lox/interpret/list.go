@@ -0,0 +1,83 @@
+package interpret
+
+import (
+	"errors"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var (
+	ErrorNotIndexable  = errors.New("Only lists and maps can be indexed.")
+	ErrorIndexRange    = errors.New("Index out of range.")
+	ErrorIndexNotAnInt = errors.New("Index must be an integer.")
+)
+
+// List is a mutable sequence of values.
+type List struct {
+	Elements []interface{}
+}
+
+func (l *List) String() string {
+	return repr(l)
+}
+
+func (i *Interpreter) VisitList(e *expr.List) interface{} {
+	i.allocList(len(e.Elements))
+	elements := make([]interface{}, len(e.Elements))
+	for j, el := range e.Elements {
+		elements[j] = i.eval(el)
+	}
+	return &List{Elements: elements}
+}
+
+func (i *Interpreter) VisitIndex(e *expr.Index) interface{} {
+	load, _ := i.element(e.Bracket, i.eval(e.Object), i.eval(e.Index))
+	return load()
+}
+
+func (i *Interpreter) VisitSetIndex(e *expr.SetIndex) interface{} {
+	_, store := i.element(e.Bracket, i.eval(e.Object), i.eval(e.Index))
+	val := i.eval(e.Value)
+	store(val)
+	return val
+}
+
+// element returns functions to load and store the element of a list or map.
+func (i *Interpreter) element(bracket tok.Token, obj, index interface{}) (load func() interface{}, store func(interface{})) {
+	switch container := obj.(type) {
+	case *List:
+		idx := i.checkIndex(bracket, container, index)
+		load = func() interface{} { return container.Elements[idx] }
+		store = func(val interface{}) { container.Elements[idx] = val }
+		return load, store
+	case *Map:
+		return i.entry(bracket, container, index)
+	}
+
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: ErrorNotIndexable,
+		Token:   bracket,
+	})
+	return nil, nil // unreachable
+}
+
+// checkIndex asserts index is in range for list.
+func (i *Interpreter) checkIndex(bracket tok.Token, list *List, index interface{}) int {
+	n, ok := index.(int64)
+	if !ok {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorIndexNotAnInt,
+			Token:   bracket,
+		})
+	}
+
+	if n < 0 || n >= int64(len(list.Elements)) {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorIndexRange,
+			Token:   bracket,
+		})
+	}
+	return int(n)
+}
@@ -0,0 +1,218 @@
+package interpret
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var (
+	ErrorUnhashable = errors.New("Lists, maps and nan cannot be map keys.")
+	ErrorNoKey      = errors.New("Key not found in map.")
+)
+
+// Map is a mutable table from keys to values. It remembers the order in which
+// keys were first added, so iterating and printing it are deterministic.
+type Map struct {
+	entries []mapEntry
+	index   map[interface{}]int // from hash key to position in entries
+}
+
+type mapEntry struct {
+	key, value interface{}
+}
+
+func NewMap() *Map {
+	return &Map{index: make(map[interface{}]int)}
+}
+
+// Get looks up the value for key.
+func (m *Map) Get(key interface{}) (interface{}, bool) {
+	h, ok := hashKey(key)
+	if !ok {
+		return nil, false
+	}
+	pos, ok := m.index[h]
+	if !ok {
+		return nil, false
+	}
+	return m.entries[pos].value, true
+}
+
+// Set adds or replaces the value for key. A replaced key keeps its position.
+func (m *Map) Set(key, value interface{}) error {
+	h, ok := hashKey(key)
+	if !ok {
+		return ErrorUnhashable
+	}
+	if pos, ok := m.index[h]; ok {
+		m.entries[pos].value = value
+		return nil
+	}
+	m.index[h] = len(m.entries)
+	m.entries = append(m.entries, mapEntry{key, value})
+	return nil
+}
+
+// Delete removes key and returns its value, if it was present.
+func (m *Map) Delete(key interface{}) (interface{}, bool) {
+	h, ok := hashKey(key)
+	if !ok {
+		return nil, false
+	}
+	pos, ok := m.index[h]
+	if !ok {
+		return nil, false
+	}
+	value := m.entries[pos].value
+	delete(m.index, h)
+	m.entries = append(m.entries[:pos], m.entries[pos+1:]...)
+	for j := pos; j < len(m.entries); j++ {
+		h, _ := hashKey(m.entries[j].key)
+		m.index[h] = j
+	}
+	return value, true
+}
+
+func (m *Map) Len() int {
+	return len(m.entries)
+}
+
+// Keys lists the keys in the order they were added.
+func (m *Map) Keys() []interface{} {
+	keys := make([]interface{}, len(m.entries))
+	for j, e := range m.entries {
+		keys[j] = e.key
+	}
+	return keys
+}
+
+func (m *Map) String() string {
+	return repr(m)
+}
+
+type (
+	nilKey    struct{}
+	numberKey string
+	timeKey   struct{ sec, nsec int64 }
+)
+
+// hashKey converts a value to a Go map key, such that values which are equal
+// in Lox have the same key. Mutable containers and nan have no key.
+func hashKey(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nilKey{}, true
+	case int64:
+		return numberKey(strconv.FormatInt(v, 10)), true
+	case *big.Int:
+		return numberKey(v.String()), true
+	case *big.Rat:
+		return numberKey(v.RatString()), true
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return nil, false
+		case math.IsInf(v, 0):
+			return numberKey(formatFloat(v)), true
+		}
+		return numberKey(new(big.Rat).SetFloat64(v).RatString()), true
+	case Time:
+		return timeKey{v.t.Unix(), int64(v.t.Nanosecond())}, true
+	case *List, *Map:
+		return nil, false
+	default:
+		return value, true
+	}
+}
+
+func (i *Interpreter) VisitMap(e *expr.Map) interface{} {
+	i.allocList(2 * len(e.Keys))
+	m := NewMap()
+	for j := range e.Keys {
+		key := i.eval(e.Keys[j])
+		if err := m.Set(key, i.eval(e.Values[j])); err != nil {
+			i.tracker.Fatal(errtrack.LoxError{
+				Message: err,
+				Token:   e.Brace,
+			})
+		}
+	}
+	return m
+}
+
+// entry returns functions to load and store the value of key in m.
+func (i *Interpreter) entry(bracket tok.Token, m *Map, key interface{}) (load func() interface{}, store func(interface{})) {
+	if _, ok := hashKey(key); !ok {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorUnhashable,
+			Token:   bracket,
+		})
+	}
+
+	load = func() interface{} {
+		val, ok := m.Get(key)
+		if !ok {
+			i.tracker.Fatal(errtrack.LoxError{
+				Message: ErrorNoKey,
+				Token:   bracket,
+			})
+		}
+		return val
+	}
+	store = func(val interface{}) {
+		if _, ok := m.Get(key); !ok {
+			i.alloc(2 * slotSize)
+		}
+		m.Set(key, val)
+	}
+	return load, store
+}
+
+var mapNatives = []*Native{
+	{name: "keys", arity: 1, fn: nativeKeys},
+	{name: "values", arity: 1, fn: nativeValues},
+	{name: "has", arity: 2, fn: nativeHas},
+	{name: "remove", arity: 2, fn: nativeRemove},
+}
+
+func (i *Interpreter) mapArg(paren tok.Token, args []interface{}, n int) *Map {
+	m, ok := args[n].(*Map)
+	if !ok {
+		i.argError(paren, n, "a map")
+	}
+	return m
+}
+
+func nativeKeys(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	m := i.mapArg(paren, args, 0)
+	i.allocList(m.Len())
+	return &List{Elements: m.Keys()}
+}
+
+func nativeValues(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	m := i.mapArg(paren, args, 0)
+	i.allocList(m.Len())
+	list := &List{Elements: make([]interface{}, len(m.entries))}
+	for j, e := range m.entries {
+		list.Elements[j] = e.value
+	}
+	return list
+}
+
+func nativeHas(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	_, ok := i.mapArg(paren, args, 0).Get(args[1])
+	return ok
+}
+
+// remove deletes a key from a map and returns its value, or nil if it was not
+// present.
+func nativeRemove(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	val, _ := i.mapArg(paren, args, 0).Delete(args[1])
+	return val
+}
@@ -0,0 +1,219 @@
+package interpret
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var (
+	ErrorNoLoader     = errors.New("Imports are not enabled.")
+	ErrorNotExported  = errors.New("Name is not exported by module.")
+	ErrorExportScope  = errors.New("Can only export from the top level of a module.")
+	ErrorOutsideRoots = errors.New("Module is outside the allowed directories.")
+)
+
+// ModuleLoader finds and reads the source code of modules.
+type ModuleLoader interface {
+	// Resolve turns an import path as written in the importing module into the
+	// canonical name of a module. From is the canonical name of the importing
+	// module.
+	Resolve(from, path string) (string, error)
+
+	// Load returns the source code of the module with the given canonical name.
+	Load(name string) ([]byte, error)
+}
+
+// FSLoader loads modules out of a file system. Imports are first resolved
+// relative to the importing module, then relative to each directory in the
+// search path.
+//
+// If Roots is set, only modules inside those directories can be imported, so
+// an import cannot climb out of them with "..". Resolve returns
+// ErrorOutsideRoots for imports that would.
+type FSLoader struct {
+	FS         fs.FS
+	SearchPath []string
+	Roots      []string
+}
+
+func (l *FSLoader) Resolve(from, name string) (string, error) {
+	candidates := []string{path.Join(path.Dir(from), name)}
+	for _, dir := range l.SearchPath {
+		candidates = append(candidates, path.Join(dir, name))
+	}
+
+	outside := false
+	for _, c := range candidates {
+		if !l.inRoots(c) {
+			outside = true
+			continue
+		}
+		if _, err := fs.Stat(l.FS, c); err == nil {
+			return c, nil
+		}
+	}
+	if outside {
+		return "", ErrorOutsideRoots
+	}
+	return "", fs.ErrNotExist
+}
+
+// inRoots reports whether the module name may be read.
+func (l *FSLoader) inRoots(name string) bool {
+	if l.Roots == nil {
+		return true
+	}
+	for _, root := range l.Roots {
+		switch root = path.Clean(root); {
+		case root == ".":
+			if name != ".." && !strings.HasPrefix(name, "../") {
+				return true
+			}
+		case name == root, strings.HasPrefix(name, root+"/"):
+			return true
+		}
+	}
+	return false
+}
+
+func (l *FSLoader) Load(name string) ([]byte, error) {
+	return fs.ReadFile(l.FS, name)
+}
+
+// Module is the result of evaluating a file. It has its own top level
+// environment enclosed by the globals, of which only exported names are visible
+// to importers.
+type Module struct {
+	name     string
+	env      *Env
+	exports  map[string]bool
+	loaded   bool
+	importer *Module
+}
+
+func newModule(tracker *errtrack.Tracker, name string, globals *Env, importer *Module) *Module {
+	return &Module{
+		name:     name,
+		env:      NewEnv(tracker, globals),
+		exports:  make(map[string]bool),
+		importer: importer,
+	}
+}
+
+// Get looks up an exported name in the module.
+func (m *Module) Get(name tok.Token) interface{} {
+	if !m.exports[name.Lexeme] {
+		m.env.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorNotExported,
+			Token:   name,
+		})
+	}
+	return m.env.Get(name)
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %q>", m.name)
+}
+
+// SetLoader sets the loader for imports, which are still disabled in
+// deterministic mode. Name is the canonical name of the program being
+// interpreted, against which its imports are resolved.
+func (i *Interpreter) SetLoader(loader ModuleLoader, name string) {
+	i.loader = loader
+	i.module.name = name
+}
+
+// importModule finds the module at path and evaluates it, unless it was
+// already imported.
+func (i *Interpreter) importModule(pathTok tok.Token) *Module {
+	if i.loader == nil || i.deterministic {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorNoLoader,
+			Token:   pathTok,
+		})
+	}
+
+	name, err := i.loader.Resolve(i.module.name, pathTok.Lit.(string))
+	if errors.Is(err, ErrorOutsideRoots) {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorOutsideRoots,
+			Token:   pathTok,
+		})
+	} else if err != nil {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Cannot find module: %v.", err),
+			Token:   pathTok,
+		})
+	}
+
+	if mod, ok := i.modules[name]; ok {
+		if !mod.loaded {
+			i.tracker.Fatal(errtrack.LoxError{
+				Message: fmt.Errorf("Import cycle: %s.", i.importChain(name)),
+				Token:   pathTok,
+			})
+		}
+		return mod
+	}
+
+	src, err := i.loader.Load(name)
+	if err != nil {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Cannot load module: %v.", err),
+			Token:   pathTok,
+		})
+	}
+
+	toks := scan.New(i.tracker, string(src)).Tokens()
+	if i.tracker.HadError() {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Module %q failed to scan.", name),
+			Token:   pathTok,
+		})
+	}
+
+	ast := parse.New(i.tracker, toks).AST()
+	if i.tracker.HadError() {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Module %q failed to parse.", name),
+			Token:   pathTok,
+		})
+	}
+
+	mod := newModule(i.tracker, name, i.globals, i.module)
+	i.modules[name] = mod
+
+	// Store the importing module and guarantee we reinstate it
+	prevModule, prevEnv := i.module, i.env
+	defer func() {
+		i.module, i.env = prevModule, prevEnv
+	}()
+
+	i.module, i.env = mod, mod.env
+	for _, st := range ast {
+		i.execute(st)
+	}
+	mod.loaded = true
+
+	return mod
+}
+
+// importChain describes how the current module came to import name.
+func (i *Interpreter) importChain(name string) string {
+	chain := []string{name}
+	for m := i.module; m != nil; m = m.importer {
+		chain = append([]string{m.name}, chain...)
+		if m.name == name {
+			break
+		}
+	}
+	return strings.Join(chain, " -> ")
+}
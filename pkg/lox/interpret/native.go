@@ -0,0 +1,94 @@
+package interpret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// defineNatives adds natives to the global environment.
+func (i *Interpreter) defineNatives(natives []*Native) {
+	for _, n := range natives {
+		i.globals.Define(n.name, n)
+	}
+}
+
+// defineModule adds a module of natives and constants to the global
+// environment, for namespaces such as math.
+func (i *Interpreter) defineModule(name string, natives []*Native, constants map[string]interface{}) {
+	mod := newModule(i.tracker, name, nil, nil)
+	mod.loaded = true
+	for _, n := range natives {
+		mod.env.Define(n.name, n)
+		mod.exports[n.name] = true
+	}
+	for k, v := range constants {
+		mod.env.Define(k, v)
+		mod.exports[k] = true
+	}
+	i.globals.Define(name, mod)
+}
+
+// argError reports that argument n of a native call has the wrong type.
+func (i *Interpreter) argError(paren tok.Token, n int, want string) {
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: fmt.Errorf("Argument %d must be %s.", n+1, want),
+		Token:   paren,
+	})
+}
+
+// argCount checks the number of arguments to a variadic native.
+func (i *Interpreter) argCount(paren tok.Token, args []interface{}, min, max int) {
+	if len(args) < min || len(args) > max {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Expected %d to %d arguments but got %d.", min, max, len(args)),
+			Token:   paren,
+		})
+	}
+}
+
+func (i *Interpreter) stringArg(paren tok.Token, args []interface{}, n int) string {
+	s, ok := args[n].(string)
+	if !ok {
+		i.argError(paren, n, "a string")
+	}
+	return s
+}
+
+func (i *Interpreter) intArg(paren tok.Token, args []interface{}, n int) int64 {
+	v, ok := args[n].(int64)
+	if !ok {
+		i.argError(paren, n, "an integer")
+	}
+	return v
+}
+
+func (i *Interpreter) numberArg(paren tok.Token, args []interface{}, n int) interface{} {
+	if !isNumber(args[n]) {
+		i.argError(paren, n, "a number")
+	}
+	return args[n]
+}
+
+func (i *Interpreter) listArg(paren tok.Token, args []interface{}, n int) *List {
+	l, ok := args[n].(*List)
+	if !ok {
+		i.argError(paren, n, "a list")
+	}
+	return l
+}
+
+// Builtins returns the names every interpreter defines in its global
+// environment, such as clock and math, in sorted order.
+func Builtins() []string {
+	i := New(errtrack.NewWithOutput(ioutil.Discard), Options{Deterministic: true})
+	names := make([]string, 0, len(i.globals.table))
+	for name := range i.globals.table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
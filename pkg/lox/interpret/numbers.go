@@ -0,0 +1,508 @@
+package interpret
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Lox numbers form a tower. From narrowest to widest:
+//
+//	int64     integers, written without a decimal point
+//	*big.Int  integers too large for an int64
+//	*big.Rat  exact decimals, written with a d suffix like 1.10d
+//	float64   floats, written with a decimal point
+//
+// Arithmetic happens at the wider of the operands' types. Integer arithmetic
+// that overflows an int64 continues with big integers, and big integers that
+// fit in an int64 are narrowed again. Dividing integers with / produces a
+// float; use ~/ for integer division.
+
+var (
+	ErrorNotAnInteger = errors.New("Operand must be an integer.")
+	ErrorDivideByZero = errors.New("Division by zero.")
+	ErrorNegShift     = errors.New("Shift count must not be negative.")
+	ErrorTooLarge     = errors.New("Integer result too large.")
+)
+
+const (
+	rankInt = iota
+	rankBig
+	rankRat
+	rankFloat
+)
+
+// maxExactFloat is the largest integer a float64 holds exactly.
+const maxExactFloat = 1<<53 - 1
+
+// maxBigBits bounds the size of big integers produced by ** and <<, which can
+// otherwise exhaust memory from small operands.
+const maxBigBits = 1 << 26
+
+func rank(value interface{}) (int, bool) {
+	switch value.(type) {
+	case int64:
+		return rankInt, true
+	case *big.Int:
+		return rankBig, true
+	case *big.Rat:
+		return rankRat, true
+	case float64:
+		return rankFloat, true
+	default:
+		return 0, false
+	}
+}
+
+func isNumber(value interface{}) bool {
+	_, ok := rank(value)
+	return ok
+}
+
+func widest(a, b interface{}) int {
+	ra, _ := rank(a)
+	rb, _ := rank(b)
+	if ra > rb {
+		return ra
+	}
+	return rb
+}
+
+func toFloat(value interface{}) float64 {
+	switch n := value.(type) {
+	case int64:
+		return float64(n)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f
+	case *big.Rat:
+		f, _ := n.Float64()
+		return f
+	case float64:
+		return n
+	default:
+		return math.NaN()
+	}
+}
+
+// toBig converts an integer to a big integer.
+func toBig(value interface{}) *big.Int {
+	if n, ok := value.(int64); ok {
+		return big.NewInt(n)
+	}
+	return value.(*big.Int)
+}
+
+// toRat converts an integer or decimal to a rational.
+func toRat(value interface{}) *big.Rat {
+	switch n := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(n)
+	case *big.Int:
+		return new(big.Rat).SetInt(n)
+	default:
+		return value.(*big.Rat)
+	}
+}
+
+// narrow returns n as an int64 if it fits.
+func narrow(n *big.Int) interface{} {
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	return n
+}
+
+// arith applies an arithmetic operator to two numbers.
+func (i *Interpreter) arith(op tok.Token, left, right interface{}) interface{} {
+	r := widest(left, right)
+	if op.Typ == tok.SLASH && r <= rankBig {
+		r = rankFloat
+	}
+
+	switch r {
+	case rankInt:
+		return i.intArith(op, left.(int64), right.(int64))
+	case rankBig:
+		return i.bigArith(op, toBig(left), toBig(right))
+	case rankRat:
+		return i.ratArith(op, toRat(left), toRat(right))
+	default:
+		return floatArith(op, toFloat(left), toFloat(right))
+	}
+}
+
+func (i *Interpreter) intArith(op tok.Token, a, b int64) interface{} {
+	var result int64
+	ok := true
+	switch op.Typ {
+	case tok.PLUS:
+		result = a + b
+		ok = (b > 0) == (result > a) || b == 0
+	case tok.MINUS:
+		result = a - b
+		ok = (b > 0) == (result < a) || b == 0
+	case tok.STAR:
+		result, ok = mulInt(a, b)
+	case tok.PERCENT, tok.TILDE_SLASH:
+		i.checkNonZero(op, b == 0)
+		if op.Typ == tok.PERCENT {
+			result = a % b
+		} else {
+			result = a / b
+			ok = !(a == math.MinInt64 && b == -1)
+		}
+	case tok.STAR_STAR:
+		if b < 0 {
+			return math.Pow(float64(a), float64(b))
+		}
+		result, ok = powInt(a, b)
+	}
+
+	if !ok {
+		return i.bigArith(op, big.NewInt(a), big.NewInt(b))
+	}
+	return result
+}
+
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	result := a * b
+	if result/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return result, true
+}
+
+// powInt raises a to the non-negative power b by squaring.
+func powInt(a, b int64) (int64, bool) {
+	result := int64(1)
+	for ok := true; b > 0; b >>= 1 {
+		if b&1 == 1 {
+			if result, ok = mulInt(result, a); !ok {
+				return 0, false
+			}
+		}
+		if b > 1 {
+			if a, ok = mulInt(a, a); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+func (i *Interpreter) bigArith(op tok.Token, a, b *big.Int) interface{} {
+	result := new(big.Int)
+	switch op.Typ {
+	case tok.PLUS:
+		result.Add(a, b)
+	case tok.MINUS:
+		result.Sub(a, b)
+	case tok.STAR:
+		result.Mul(a, b)
+	case tok.PERCENT:
+		i.checkNonZero(op, b.Sign() == 0)
+		result.Rem(a, b)
+	case tok.TILDE_SLASH:
+		i.checkNonZero(op, b.Sign() == 0)
+		result.Quo(a, b)
+	case tok.STAR_STAR:
+		if b.Sign() < 0 {
+			return math.Pow(toFloat(a), toFloat(b))
+		}
+		if a.CmpAbs(big.NewInt(1)) > 0 {
+			i.checkBits(op, b, a.BitLen())
+		}
+		result.Exp(a, b, nil)
+	}
+	return narrow(result)
+}
+
+func (i *Interpreter) ratArith(op tok.Token, a, b *big.Rat) interface{} {
+	result := new(big.Rat)
+	switch op.Typ {
+	case tok.PLUS:
+		result.Add(a, b)
+	case tok.MINUS:
+		result.Sub(a, b)
+	case tok.STAR:
+		result.Mul(a, b)
+	case tok.SLASH:
+		i.checkNonZero(op, b.Sign() == 0)
+		result.Quo(a, b)
+	case tok.TILDE_SLASH:
+		i.checkNonZero(op, b.Sign() == 0)
+		q := new(big.Rat).Quo(a, b)
+		return narrow(new(big.Int).Quo(q.Num(), q.Denom()))
+	case tok.PERCENT:
+		i.checkNonZero(op, b.Sign() == 0)
+		q := new(big.Rat).Quo(a, b)
+		trunc := new(big.Rat).SetInt(new(big.Int).Quo(q.Num(), q.Denom()))
+		result.Sub(a, trunc.Mul(trunc, b))
+	case tok.STAR_STAR:
+		if !b.IsInt() || !b.Num().IsInt64() {
+			return math.Pow(toFloat(a), toFloat(b))
+		}
+		exp := b.Num().Int64()
+		if exp < 0 {
+			i.checkNonZero(op, a.Sign() == 0)
+			a, exp = new(big.Rat).Inv(a), -exp
+		}
+		bits := a.Num().BitLen()
+		if d := a.Denom().BitLen(); d > bits {
+			bits = d
+		}
+		e := big.NewInt(exp)
+		i.checkBits(op, e, bits)
+		result.SetFrac(new(big.Int).Exp(a.Num(), e, nil), new(big.Int).Exp(a.Denom(), e, nil))
+	}
+	return result
+}
+
+func floatArith(op tok.Token, a, b float64) float64 {
+	switch op.Typ {
+	case tok.PLUS:
+		return a + b
+	case tok.MINUS:
+		return a - b
+	case tok.STAR:
+		return a * b
+	case tok.SLASH:
+		return a / b
+	case tok.PERCENT:
+		return math.Mod(a, b)
+	case tok.TILDE_SLASH:
+		return math.Trunc(a / b)
+	case tok.STAR_STAR:
+		return math.Pow(a, b)
+	default:
+		return math.NaN()
+	}
+}
+
+func (i *Interpreter) checkNonZero(op tok.Token, isZero bool) {
+	if isZero {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorDivideByZero,
+			Token:   op,
+		})
+	}
+}
+
+// checkBits fails if a number of the given bit length scaled by times would
+// exceed maxBigBits.
+func (i *Interpreter) checkBits(op tok.Token, times *big.Int, bits int) {
+	limit := big.NewInt(maxBigBits / int64(bits+1))
+	if times.Cmp(limit) > 0 {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorTooLarge,
+			Token:   op,
+		})
+	}
+}
+
+func (i *Interpreter) negate(op tok.Token, value interface{}) interface{} {
+	switch n := value.(type) {
+	case int64:
+		if n == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(n))
+		}
+		return -n
+	case *big.Int:
+		return narrow(new(big.Int).Neg(n))
+	case *big.Rat:
+		return new(big.Rat).Neg(n)
+	default:
+		return -toFloat(value)
+	}
+}
+
+// compare orders two numbers. It returns false if they are unordered, which
+// happens when one is NaN.
+func compare(a, b interface{}) (int, bool) {
+	switch widest(a, b) {
+	case rankInt:
+		x, y := a.(int64), b.(int64)
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case rankBig, rankRat:
+		return toRat(a).Cmp(toRat(b)), true
+	}
+
+	x, y := toFloat(a), toFloat(b)
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	case x == y:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// checkInteger asserts value is an integer and returns it as an int64 or
+// *big.Int. Floats with an exact integer value are accepted too.
+func (i *Interpreter) checkInteger(op tok.Token, value interface{}) interface{} {
+	switch n := value.(type) {
+	case int64, *big.Int:
+		return n
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) <= maxExactFloat {
+			return int64(n)
+		}
+	}
+
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: ErrorNotAnInteger,
+		Token:   op,
+	})
+	return nil // unreachable
+}
+
+func (i *Interpreter) bitwise(op tok.Token, left, right interface{}) interface{} {
+	left, right = i.checkInteger(op, left), i.checkInteger(op, right)
+	if widest(left, right) == rankInt {
+		if result, ok := i.intBitwise(op, left.(int64), right.(int64)); ok {
+			return result
+		}
+	}
+
+	a, b := toBig(left), toBig(right)
+	result := new(big.Int)
+	switch op.Typ {
+	case tok.AMPERSAND:
+		result.And(a, b)
+	case tok.PIPE:
+		result.Or(a, b)
+	case tok.CARET:
+		result.Xor(a, b)
+	case tok.LESS_LESS, tok.GREATER_GREATER:
+		if b.Sign() < 0 {
+			i.tracker.Fatal(errtrack.LoxError{
+				Message: ErrorNegShift,
+				Token:   op,
+			})
+		}
+		if op.Typ == tok.GREATER_GREATER {
+			if !b.IsUint64() || b.Uint64() > uint64(a.BitLen()) {
+				// Everything is shifted out, leaving only the sign.
+				return int64(a.Sign() >> 1)
+			}
+			result.Rsh(a, uint(b.Uint64()))
+		} else {
+			i.checkBits(op, b, 0)
+			result.Lsh(a, uint(b.Uint64()))
+		}
+	}
+	return narrow(result)
+}
+
+// intBitwise is the fast path of bitwise. It returns false if the result does
+// not fit in an int64.
+func (i *Interpreter) intBitwise(op tok.Token, a, b int64) (int64, bool) {
+	switch op.Typ {
+	case tok.AMPERSAND:
+		return a & b, true
+	case tok.PIPE:
+		return a | b, true
+	case tok.CARET:
+		return a ^ b, true
+	}
+
+	if b < 0 || b >= 64 {
+		// Leave negative shifts and large shifts to the slow path.
+		return 0, false
+	}
+	if op.Typ == tok.GREATER_GREATER {
+		return a >> uint64(b), true
+	}
+
+	result := a << uint64(b)
+	return result, result>>uint64(b) == a
+}
+
+// complement is bitwise not.
+func (i *Interpreter) complement(op tok.Token, value interface{}) interface{} {
+	switch n := i.checkInteger(op, value).(type) {
+	case int64:
+		return ^n
+	default:
+		return narrow(new(big.Int).Not(n.(*big.Int)))
+	}
+}
+
+// formatFloat prints a float so that it always reads back as a float.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	var s string
+	if abs := math.Abs(f); abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	} else {
+		s = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// formatRat prints a decimal so that it reads back as the same decimal. Those
+// with a finite decimal expansion are printed in full, like 1.1d, and the rest
+// as fractions, like 1/3d.
+func formatRat(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String() + "d"
+	}
+
+	// The expansion is finite iff the denominator has no prime factors other
+	// than 2 and 5. The number of digits needed is the larger exponent.
+	denom := new(big.Int).Set(r.Denom())
+	twos := denom.TrailingZeroBits()
+	denom.Rsh(denom, twos)
+
+	fives := uint(0)
+	five, rem := big.NewInt(5), new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(denom, five, rem)
+		if m.Sign() != 0 {
+			break
+		}
+		denom = q
+		fives++
+	}
+
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return r.String() + "d"
+	}
+
+	digits := twos
+	if fives > digits {
+		digits = fives
+	}
+	return r.FloatString(int(digits)) + "d"
+}
@@ -0,0 +1,145 @@
+package interpret
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var ErrorNoFiles = errors.New("File access is not enabled.")
+
+var ioNatives = []*Native{
+	{name: "readFile", arity: 1, fn: nativeReadFile},
+	{name: "writeFile", arity: 2, fn: nativeWriteFile},
+	{name: "readLines", arity: 1, fn: nativeReadLines},
+	{name: "listDir", arity: 1, fn: nativeListDir},
+	{name: "exists", arity: 1, fn: nativeExists},
+	{name: "input", arity: -1, fn: nativeInput},
+	{name: "readLine", arity: 0, fn: nativeReadLine},
+	{name: "eprint", arity: 1, fn: nativeEprint},
+}
+
+// fileSystem returns the file system scripts may use, or stops the script if
+// there is none.
+func (i *Interpreter) fileSystem(paren tok.Token) FileSystem {
+	if i.files == nil || i.deterministic {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorNoFiles,
+			Token:   paren,
+		})
+	}
+	return i.files
+}
+
+func (i *Interpreter) fileError(paren tok.Token, err error) {
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: fmt.Errorf("File error: %v.", err),
+		Token:   paren,
+	})
+}
+
+func (i *Interpreter) readFile(paren tok.Token, name string) string {
+	fsys, name := i.fileSystem(paren), cleanPath(name)
+	if info, err := fs.Stat(fsys, name); err == nil {
+		i.alloc(info.Size())
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		i.fileError(paren, err)
+	}
+	return string(data)
+}
+
+func nativeReadFile(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return i.readFile(paren, i.stringArg(paren, args, 0))
+}
+
+// writeFile replaces the contents of a file with a string, creating the file if
+// needed.
+func nativeWriteFile(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	name, data := i.stringArg(paren, args, 0), i.stringArg(paren, args, 1)
+	if err := i.fileSystem(paren).WriteFile(cleanPath(name), []byte(data)); err != nil {
+		i.fileError(paren, err)
+	}
+	return nil
+}
+
+// readLines lists the lines of a file without their line endings.
+func nativeReadLines(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	data := i.readFile(paren, i.stringArg(paren, args, 0))
+	list := &List{}
+	i.allocList(strings.Count(data, "\n") + 1)
+	for data != "" {
+		var line string
+		line, data = cutLine(data)
+		list.Elements = append(list.Elements, line)
+	}
+	return list
+}
+
+// cutLine splits the first line of s, without its line ending, from the rest.
+func cutLine(s string) (line, rest string) {
+	end := strings.IndexByte(s, '\n')
+	if end < 0 {
+		return s, ""
+	}
+	return strings.TrimSuffix(s[:end], "\r"), s[end+1:]
+}
+
+// listDir lists the names of the entries in a directory, in sorted order.
+func nativeListDir(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	entries, err := fs.ReadDir(i.fileSystem(paren), cleanPath(i.stringArg(paren, args, 0)))
+	if err != nil {
+		i.fileError(paren, err)
+	}
+	i.allocList(len(entries))
+	list := &List{Elements: make([]interface{}, len(entries))}
+	for j, e := range entries {
+		list.Elements[j] = e.Name()
+	}
+	return list
+}
+
+func nativeExists(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	_, err := fs.Stat(i.fileSystem(paren), cleanPath(i.stringArg(paren, args, 0)))
+	return err == nil
+}
+
+// input writes an optional prompt and then reads a line like readLine.
+func nativeInput(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	i.argCount(paren, args, 0, 1)
+	if len(args) == 1 {
+		fmt.Fprint(i.out, i.stringArg(paren, args, 0))
+	}
+	return nativeReadLine(i, paren, nil)
+}
+
+// readLine reads a line of input without its line ending, or returns nil at
+// the end of input.
+func nativeReadLine(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	i.Flush()
+	line, err := i.in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Cannot read input: %v.", err),
+			Token:   paren,
+		})
+	}
+	if line == "" && err != nil {
+		return nil
+	}
+	line, _ = cutLine(line)
+	return line
+}
+
+// eprint is like print, but writes to standard error.
+func nativeEprint(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	i.Flush()
+	fmt.Fprintln(i.errOut, Stringify(args[0]))
+	return nil
+}
@@ -0,0 +1,92 @@
+package interpret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIONatives(t *testing.T) {
+	files := fstest.MapFS{
+		"config.txt":     {Data: []byte("name=lox\r\nversion=1\n")},
+		"empty.txt":      {Data: []byte("")},
+		"dir/b.txt":      {Data: []byte("b")},
+		"dir/a.txt":      {Data: []byte("a")},
+		"dir/sub/c.txt":  {Data: []byte("c")},
+		"no-newline.txt": {Data: []byte("one\ntwo")},
+	}
+	readOnly := func(i *Interpreter) {
+		i.SetFiles(ReadOnlyFS(files))
+		i.SetInput(strings.NewReader("first\r\nsecond\nlast"))
+	}
+
+	table := map[string]scriptTest{
+		"read file":         {in: `print readFile("dir/a.txt");`, want: "a"},
+		"read rooted":       {in: `print readFile("/dir/a.txt");`, want: "a"},
+		"read escape":       {in: `print readFile("../../dir/./a.txt");`, want: "a"},
+		"read missing":      {in: `print readFile("nope.txt");`, wanterr: true},
+		"read lines":        {in: `print readLines("config.txt");`, want: `["name=lox", "version=1"]`},
+		"read lines no end": {in: `print readLines("no-newline.txt");`, want: `["one", "two"]`},
+		"read lines empty":  {in: `print readLines("empty.txt");`, want: `[]`},
+		"list dir":          {in: `print listDir("dir");`, want: `["a.txt", "b.txt", "sub"]`},
+		"list root":         {in: `print len(listDir("/"));`, want: "4"},
+		"list file":         {in: `print listDir("config.txt");`, wanterr: true},
+		"exists":            {in: `print exists("dir/sub");`, want: "true"},
+		"not exists":        {in: `print exists("dir/nope");`, want: "false"},
+		"write read-only":   {in: `writeFile("new.txt", "x");`, wanterr: true},
+		"read line":         {in: `print readLine(); print readLine();`, want: "first\nsecond"},
+		"read line eof":     {in: `readLine(); readLine(); print readLine(); print readLine();`, want: "last\nnil"},
+		"input":             {in: `var x = input("name? "); print x;`, want: "name? first"},
+		"input no prompt":   {in: `print input();`, want: "first"},
+		"input too many":    {in: `input("a", "b");`, wanterr: true},
+		"wrong type":        {in: `readFile(1);`, wanterr: true},
+	}
+
+	runTable(t, table, readOnly)
+}
+
+func TestIONoFiles(t *testing.T) {
+	for _, in := range []string{
+		`readFile("x");`,
+		`writeFile("x", "y");`,
+		`readLines("x");`,
+		`listDir(".");`,
+		`exists("x");`,
+	} {
+		if _, errs := interpretString(t, in); len(errs) == 0 {
+			t.Errorf("%s: wanted an error but got none", in)
+		}
+	}
+}
+
+func TestDirFS(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "sandbox")
+	if err := os.Mkdir(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	in := `
+	writeFile("report.txt", "ok");
+	writeFile("../outside.txt", "contained");
+	print readFile("report.txt");
+	print listDir(".");
+	`
+	got, errs := interpretWith(t, in, func(i *Interpreter) {
+		i.SetFiles(DirFS(dir))
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected error: %q", errs)
+	}
+	if diff := cmp.Diff(got, "ok\n[\"outside.txt\", \"report.txt\"]\n"); diff != "" {
+		t.Errorf("incorrect output (-got,+want): %s", diff)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "outside.txt")); err == nil {
+		t.Errorf("script wrote outside of its directory")
+	}
+}
@@ -0,0 +1,308 @@
+package interpret
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// maxJSONDepth is how deeply stringify nests lists and maps, the limit
+// encoding/json puts on parse.
+const maxJSONDepth = 10000
+
+var (
+	ErrorJSONCycle = errors.New("Cannot encode a value that contains itself as JSON.")
+	ErrorJSONDepth = fmt.Errorf("Cannot encode a value nested more than %d deep as JSON.", maxJSONDepth)
+)
+
+// JSON objects decode to maps with their keys in the original order, integers
+// to integers of any size, and other numbers to floats. Encoding reverses this,
+// so that values round trip.
+var jsonNatives = []*Native{
+	{name: "parse", arity: 1, fn: nativeJSONParse},
+	{name: "stringify", arity: -1, fn: nativeJSONStringify},
+}
+
+func nativeJSONParse(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	src := i.stringArg(paren, args, 0)
+	value, err := decodeJSON(src, i.alloc)
+	if err != nil {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: err,
+			Token:   paren,
+		})
+	}
+	return value
+}
+
+// maxJSONIndent is the most spaces stringify indents by, as in JavaScript.
+const maxJSONIndent = 10
+
+// stringify encodes a value as JSON. The optional second argument indents
+// nested values by a number of spaces, up to maxJSONIndent, or by a string.
+func nativeJSONStringify(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	i.argCount(paren, args, 1, 2)
+
+	e := &jsonEncoder{seen: make(map[interface{}]bool), alloc: i.alloc}
+	if len(args) == 2 {
+		switch indent := args[1].(type) {
+		case nil:
+		case int64:
+			if indent < 0 {
+				i.tracker.Fatal(errtrack.LoxError{
+					Message: ErrorNegativeCount,
+					Token:   paren,
+				})
+			}
+			if indent > maxJSONIndent {
+				indent = maxJSONIndent
+			}
+			e.indent = strings.Repeat(" ", int(indent))
+		case string:
+			e.indent = indent
+		default:
+			i.argError(paren, 1, "an integer or string")
+		}
+	}
+
+	if err := e.encode(args[0], 0); err != nil {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: err,
+			Token:   paren,
+		})
+	}
+	i.alloc(int64(e.b.Len()))
+	return e.b.String()
+}
+
+// jsonDecoder builds Lox values from the token stream of a json.Decoder, which
+// unlike json.Unmarshal preserves the order of object keys.
+type jsonDecoder struct {
+	src   string
+	dec   *json.Decoder
+	alloc func(int64) // accounts for the values decoded
+}
+
+func decodeJSON(src string, alloc func(int64)) (interface{}, error) {
+	d := &jsonDecoder{src: src, dec: json.NewDecoder(strings.NewReader(src)), alloc: alloc}
+	d.dec.UseNumber()
+
+	value, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.dec.Token(); err != io.EOF {
+		return nil, d.errorf("unexpected data after top-level value")
+	}
+	return value, nil
+}
+
+func (d *jsonDecoder) value() (interface{}, error) {
+	t, err := d.dec.Token()
+	if err != nil {
+		return nil, d.wrap(err)
+	}
+
+	switch t := t.(type) {
+	case json.Delim:
+		if t == '[' {
+			return d.array()
+		}
+		return d.object()
+	case json.Number:
+		return jsonNumber(t)
+	case string:
+		d.alloc(int64(len(t)))
+		return t, nil
+	default:
+		// Bools and nil are already Lox values.
+		return t, nil
+	}
+}
+
+func (d *jsonDecoder) array() (interface{}, error) {
+	d.alloc(objectSize)
+	list := &List{Elements: []interface{}{}}
+	for d.dec.More() {
+		d.alloc(slotSize)
+		el, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		list.Elements = append(list.Elements, el)
+	}
+	if _, err := d.dec.Token(); err != nil {
+		return nil, d.wrap(err)
+	}
+	return list, nil
+}
+
+func (d *jsonDecoder) object() (interface{}, error) {
+	d.alloc(objectSize)
+	m := NewMap()
+	for d.dec.More() {
+		d.alloc(2 * slotSize)
+		key, err := d.dec.Token()
+		if err != nil {
+			return nil, d.wrap(err)
+		}
+		val, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		m.Set(key, val)
+	}
+	if _, err := d.dec.Token(); err != nil {
+		return nil, d.wrap(err)
+	}
+	return m, nil
+}
+
+func jsonNumber(n json.Number) (interface{}, error) {
+	if !strings.ContainsAny(string(n), ".eE") {
+		if b, ok := new(big.Int).SetString(string(n), 10); ok {
+			return narrow(b), nil
+		}
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JSON number %s.", n)
+	}
+	return f, nil
+}
+
+// wrap positions an error from the decoder.
+func (d *jsonDecoder) wrap(err error) error {
+	var syntax *json.SyntaxError
+	switch {
+	case errors.As(err, &syntax):
+		// The offset is just after the offending byte.
+		return d.errorAt(syntax.Offset-1, syntax.Error())
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return d.errorAt(int64(len(d.src)), "unexpected end of JSON input")
+	default:
+		return d.errorf("%v", err)
+	}
+}
+
+func (d *jsonDecoder) errorf(format string, args ...interface{}) error {
+	return d.errorAt(d.dec.InputOffset(), fmt.Sprintf(format, args...))
+}
+
+// errorAt describes an error at a byte offset as a line and column.
+func (d *jsonDecoder) errorAt(offset int64, msg string) error {
+	if offset < 0 {
+		offset = 0
+	} else if offset > int64(len(d.src)) {
+		offset = int64(len(d.src))
+	}
+	before := d.src[:offset]
+	line := strings.Count(before, "\n") + 1
+	col := len([]rune(before[strings.LastIndexByte(before, '\n')+1:])) + 1
+	return fmt.Errorf("Invalid JSON at %d:%d: %s.", line, col, msg)
+}
+
+type jsonEncoder struct {
+	b      strings.Builder
+	indent string
+	seen   map[interface{}]bool // containers being encoded, to catch cycles
+	alloc  func(int64)          // accounts for indentation, which can grow quickly
+}
+
+func (e *jsonEncoder) encode(value interface{}, depth int) error {
+	switch v := value.(type) {
+	case nil:
+		e.b.WriteString("null")
+	case bool:
+		fmt.Fprint(&e.b, v)
+	case string:
+		e.str(v)
+	case int64, *big.Int:
+		fmt.Fprint(&e.b, v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("Cannot encode %s as JSON.", formatFloat(v))
+		}
+		e.b.WriteString(formatFloat(v))
+	case *big.Rat:
+		// A decimal that cannot be written exactly would not round trip.
+		s := formatRat(v)
+		if strings.Contains(s, "/") {
+			return fmt.Errorf("Cannot encode %s as JSON.", s)
+		}
+		e.b.WriteString(strings.TrimSuffix(s, "d"))
+	case *List:
+		return e.container(v, depth, '[', ']', len(v.Elements), func(j int) error {
+			return e.encode(v.Elements[j], depth+1)
+		})
+	case *Map:
+		return e.container(v, depth, '{', '}', len(v.entries), func(j int) error {
+			key, ok := v.entries[j].key.(string)
+			if !ok {
+				return fmt.Errorf("Cannot encode map key %s as JSON.", repr(v.entries[j].key))
+			}
+			e.str(key)
+			e.b.WriteByte(':')
+			if e.indent != "" {
+				e.b.WriteByte(' ')
+			}
+			return e.encode(v.entries[j].value, depth+1)
+		})
+	default:
+		return fmt.Errorf("Cannot encode %s as JSON.", Stringify(v))
+	}
+	return nil
+}
+
+// container writes the n elements of a list or map between open and close.
+func (e *jsonEncoder) container(v interface{}, depth int, open, close byte, n int, element func(int) error) error {
+	if e.seen[v] {
+		return ErrorJSONCycle
+	}
+	if depth >= maxJSONDepth {
+		return ErrorJSONDepth
+	}
+	e.seen[v] = true
+	defer delete(e.seen, v)
+
+	e.b.WriteByte(open)
+	for j := 0; j < n; j++ {
+		if j > 0 {
+			e.b.WriteByte(',')
+		}
+		e.newline(depth + 1)
+		if err := element(j); err != nil {
+			return err
+		}
+	}
+	if n > 0 {
+		e.newline(depth)
+	}
+	e.b.WriteByte(close)
+	return nil
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent == "" {
+		return
+	}
+	e.alloc(int64(len(e.indent) * depth))
+	e.b.WriteByte('\n')
+	e.b.WriteString(strings.Repeat(e.indent, depth))
+}
+
+func (e *jsonEncoder) str(s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	e.b.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,66 @@
+package interpret
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONNatives(t *testing.T) {
+	// Lox strings cannot contain quotes, so JSON text is given to scripts in
+	// the global src.
+	table := map[string]scriptTest{
+		"parse object":       {src: `{"b": [1, 2.5, true], "a": null}`, in: `print json.parse(src);`, want: `{"b": [1, 2.5, true], "a": nil}`},
+		"parse index":        {src: `{"a": {"b": 3}}`, in: `print json.parse(src)["a"]["b"];`, want: "3"},
+		"parse big int":      {in: `print json.parse("123456789012345678901234567890") + 1;`, want: "123456789012345678901234567891"},
+		"parse float":        {in: `print json.parse("1.0"); print json.parse("1e3");`, want: "1.0\n1000.0"},
+		"parse string":       {src: `"café \"x\""`, in: `print json.parse(src);`, want: `café "x"`},
+		"parse duplicate":    {src: `{"a": 1, "b": 2, "a": 3}`, in: `print json.parse(src);`, want: `{"a": 3, "b": 2}`},
+		"parse empty":        {in: `print json.parse("[]"); print json.parse("{}");`, want: "[]\n{}"},
+		"parse malformed":    {in: `json.parse("[1,]");`, wanterr: true},
+		"parse truncated":    {src: `{"a": `, in: `json.parse(src);`, wanterr: true},
+		"parse trailing":     {in: `json.parse("1 2");`, wanterr: true},
+		"parse blank":        {in: `json.parse("");`, wanterr: true},
+		"stringify":          {in: `print json.stringify({"a": [1, 2.0, nil], "b": "x<y"});`, want: `{"a":[1,2.0,null],"b":"x<y"}`},
+		"stringify indent":   {in: `print json.stringify({"a": [1], "b": {}}, 2);`, want: "{\n  \"a\": [\n    1\n  ],\n  \"b\": {}\n}"},
+		"stringify wide":     {in: `print json.stringify([1], 9223372036854775807);`, want: "[\n          1\n]"},
+		"stringify string":   {in: `print json.stringify([1], "--");`, want: "[\n--1\n]"},
+		"stringify nil":      {in: `print json.stringify([true], nil);`, want: "[true]"},
+		"stringify quote":    {src: `a"b`, in: `print json.stringify(src);`, want: `"a\"b"`},
+		"stringify big":      {in: `print json.stringify(2 ** 100);`, want: "1267650600228229401496703205376"},
+		"stringify dec":      {in: `print json.stringify(1.10d);`, want: "1.1"},
+		"stringify third":    {in: `json.stringify(1/3d);`, wanterr: true},
+		"stringify nan":      {in: `json.stringify(math.nan);`, wanterr: true},
+		"stringify key":      {in: `json.stringify({1: 2});`, wanterr: true},
+		"stringify fn":       {in: `json.stringify(clock);`, wanterr: true},
+		"stringify cycle":    {in: `var xs = [1]; xs[0] = xs; json.stringify(xs);`, wanterr: true},
+		"stringify deepest":  {in: `var xs = reduce(runes(repeat("a", 9999)), (acc, c) => [acc], []); print json.parse(json.stringify(xs)) != nil;`, want: "true"},
+		"stringify too deep": {in: `json.stringify(reduce(runes(repeat("a", 10000)), (acc, c) => {"k": acc}, {}));`, wanterr: true},
+		"stringify shared":   {in: `var xs = [1]; print json.stringify([xs, xs]);`, want: "[[1],[1]]"},
+		"stringify arity":    {in: `json.stringify();`, wanterr: true},
+		"round trip": {
+			src:  `{"z":[1,-2.5,1e+21,"\n",123456789012345678901],"a":{"k":false}}`,
+			in:   `print json.stringify(json.parse(src)) == src;`,
+			want: "true",
+		},
+	}
+
+	runTable(t, table)
+}
+
+func TestJSONErrorPosition(t *testing.T) {
+	table := map[string]string{
+		"{\n  \"a\": tru\n}": "Invalid JSON at 2:11:",
+		"[1,]":               "Invalid JSON at 1:3:",
+		"[1":                 "Invalid JSON at 1:2:",
+		"1 x":                "Invalid JSON at 1:",
+	}
+
+	for src, want := range table {
+		_, err := decodeJSON(src, func(int64) {})
+		if err == nil {
+			t.Errorf("%q: wanted an error but got none", src)
+		} else if !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%q: error %q does not start with %q", src, err, want)
+		}
+	}
+}
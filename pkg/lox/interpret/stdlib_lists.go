@@ -0,0 +1,237 @@
+package interpret
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var (
+	ErrorEmptyList = errors.New("List must not be empty.")
+	ErrorUnordered = errors.New("Can only order numbers with numbers and strings with strings.")
+)
+
+// List natives never modify the lists they are given. Errors raised by the Lox
+// functions they call unwind through them like any other runtime error.
+var listNatives = []*Native{
+	{name: "map", arity: 2, fn: nativeMap},
+	{name: "filter", arity: 2, fn: nativeFilter},
+	{name: "reduce", arity: -1, fn: nativeReduce},
+	{name: "any", arity: -1, fn: predicateNative(true)},
+	{name: "all", arity: -1, fn: predicateNative(false)},
+	{name: "zip", arity: -1, fn: nativeZip},
+	{name: "enumerate", arity: 1, fn: nativeEnumerate},
+	{name: "sorted", arity: -1, fn: nativeSorted},
+	{name: "reverse", arity: 1, fn: nativeReverse},
+	{name: "sum", arity: 1, fn: nativeSum},
+	{name: "min", arity: -1, fn: extremeKeyNative(-1)},
+	{name: "max", arity: -1, fn: extremeKeyNative(1)},
+}
+
+func nativeMap(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	list := i.listArg(paren, args, 0)
+	i.allocList(len(list.Elements))
+	result := &List{Elements: make([]interface{}, len(list.Elements))}
+	for j, el := range list.Elements {
+		result.Elements[j] = i.call(paren, args[1], []interface{}{el})
+	}
+	return result
+}
+
+func nativeFilter(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	list := i.listArg(paren, args, 0)
+	i.allocList(len(list.Elements))
+	result := &List{Elements: []interface{}{}}
+	for _, el := range list.Elements {
+		if truthy(i.call(paren, args[1], []interface{}{el})) {
+			result.Elements = append(result.Elements, el)
+		}
+	}
+	return result
+}
+
+// reduce folds a list from the left with a function of the accumulator and
+// each element. Without an initial value the first element is used.
+func nativeReduce(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	i.argCount(paren, args, 2, 3)
+	elements := i.listArg(paren, args, 0).Elements
+
+	var acc interface{}
+	if len(args) == 3 {
+		acc = args[2]
+	} else if len(elements) == 0 {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorEmptyList,
+			Token:   paren,
+		})
+	} else {
+		acc, elements = elements[0], elements[1:]
+	}
+
+	for _, el := range elements {
+		acc = i.call(paren, args[1], []interface{}{acc, el})
+	}
+	return acc
+}
+
+// predicateNative makes any (stopOn true) or all (stopOn false). They test the
+// truth of each element, or of a function of each element if one is given.
+func predicateNative(stopOn bool) func(*Interpreter, tok.Token, []interface{}) interface{} {
+	return func(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+		i.argCount(paren, args, 1, 2)
+		for _, el := range i.listArg(paren, args, 0).Elements {
+			if len(args) == 2 {
+				el = i.call(paren, args[1], []interface{}{el})
+			}
+			if truthy(el) == stopOn {
+				return stopOn
+			}
+		}
+		return !stopOn
+	}
+}
+
+// zip pairs up the elements of lists, stopping at the end of the shortest.
+func nativeZip(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	lists := make([]*List, len(args))
+	n := 0
+	for j := range args {
+		lists[j] = i.listArg(paren, args, j)
+		if j == 0 || len(lists[j].Elements) < n {
+			n = len(lists[j].Elements)
+		}
+	}
+
+	i.allocList(n)
+	i.allocN(int64(n), objectSize+slotSize*int64(len(lists)))
+	result := &List{Elements: make([]interface{}, 0, n)}
+	for j := 0; j < n; j++ {
+		tuple := &List{Elements: make([]interface{}, len(lists))}
+		for k, l := range lists {
+			tuple.Elements[k] = l.Elements[j]
+		}
+		result.Elements = append(result.Elements, tuple)
+	}
+	return result
+}
+
+// enumerate pairs each element with its index.
+func nativeEnumerate(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	list := i.listArg(paren, args, 0)
+	i.allocList(len(list.Elements))
+	i.allocN(int64(len(list.Elements)), objectSize+2*slotSize)
+	result := &List{Elements: make([]interface{}, len(list.Elements))}
+	for j, el := range list.Elements {
+		result.Elements[j] = &List{Elements: []interface{}{int64(j), el}}
+	}
+	return result
+}
+
+// sortKeys applies the optional key function in args[n] to each element of
+// list.
+func (i *Interpreter) sortKeys(paren tok.Token, list *List, args []interface{}, n int) []interface{} {
+	if len(args) <= n {
+		return list.Elements
+	}
+	i.allocList(len(list.Elements))
+	keys := make([]interface{}, len(list.Elements))
+	for j, el := range list.Elements {
+		keys[j] = i.call(paren, args[n], []interface{}{el})
+	}
+	return keys
+}
+
+// order compares two values as < does for numbers, and extends it to strings.
+func (i *Interpreter) order(paren tok.Token, a, b interface{}) int {
+	if isNumber(a) && isNumber(b) {
+		if c, ok := compare(a, b); ok {
+			return c
+		}
+	} else if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return strings.Compare(sa, sb)
+		}
+	}
+
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: fmt.Errorf("%s Got %s and %s.", ErrorUnordered, repr(a), repr(b)),
+		Token:   paren,
+	})
+	return 0 // unreachable
+}
+
+// sorted returns a new list in ascending order of the elements, or of a
+// function of each element if one is given. The sort is stable and calls the
+// key function once per element.
+func nativeSorted(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	i.argCount(paren, args, 1, 2)
+	list := i.listArg(paren, args, 0)
+	keys := i.sortKeys(paren, list, args, 1)
+	i.allocList(2 * len(list.Elements))
+
+	perm := make([]int, len(list.Elements))
+	for j := range perm {
+		perm[j] = j
+	}
+	sort.SliceStable(perm, func(a, b int) bool {
+		return i.order(paren, keys[perm[a]], keys[perm[b]]) < 0
+	})
+
+	result := &List{Elements: make([]interface{}, len(perm))}
+	for j, p := range perm {
+		result.Elements[j] = list.Elements[p]
+	}
+	return result
+}
+
+func nativeReverse(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	list := i.listArg(paren, args, 0)
+	n := len(list.Elements)
+	i.allocList(n)
+	result := &List{Elements: make([]interface{}, n)}
+	for j, el := range list.Elements {
+		result.Elements[n-1-j] = el
+	}
+	return result
+}
+
+// sum adds up a list of numbers, starting from 0.
+func nativeSum(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	op := paren
+	op.Typ = tok.PLUS
+
+	var total interface{} = int64(0)
+	for _, el := range i.listArg(paren, args, 0).Elements {
+		i.checkNumber(paren, el)
+		total = i.arith(op, total, el)
+	}
+	return total
+}
+
+// extremeKeyNative finds the first least (sign -1) or greatest (sign 1)
+// element of a list, optionally by a function of each element.
+func extremeKeyNative(sign int) func(*Interpreter, tok.Token, []interface{}) interface{} {
+	return func(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+		i.argCount(paren, args, 1, 2)
+		list := i.listArg(paren, args, 0)
+		if len(list.Elements) == 0 {
+			i.tracker.Fatal(errtrack.LoxError{
+				Message: ErrorEmptyList,
+				Token:   paren,
+			})
+		}
+
+		keys := i.sortKeys(paren, list, args, 1)
+		best := 0
+		for j := 1; j < len(keys); j++ {
+			if i.order(paren, keys[j], keys[best])*sign > 0 {
+				best = j
+			}
+		}
+		return list.Elements[best]
+	}
+}
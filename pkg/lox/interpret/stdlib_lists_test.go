@@ -0,0 +1,65 @@
+package interpret
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListNatives(t *testing.T) {
+	table := map[string]scriptTest{
+		"map":              {in: `print map([1, 2, 3], x => x * 2);`, want: "[2, 4, 6]"},
+		"map native":       {in: `print map(["a", "b"], upper);`, want: `["A", "B"]`},
+		"map not callable": {in: `map([1], 1);`, wanterr: true},
+		"map arity":        {in: `map([1], fn (a, b) { return a; });`, wanterr: true},
+		"map empty":        {in: `print map([], 1);`, want: "[]"},
+		"filter":           {in: `print filter([1, 2, 3, 4], x => x % 2 == 0);`, want: "[2, 4]"},
+		"reduce":           {in: `print reduce([1, 2, 3], (a, b) => a + b);`, want: "6"},
+		"reduce initial":   {in: `print reduce(["a", "b"], (a, b) => a + b, ">");`, want: ">ab"},
+		"reduce empty":     {in: `reduce([], (a, b) => a + b);`, wanterr: true},
+		"reduce empty ok":  {in: `print reduce([], (a, b) => a + b, 0);`, want: "0"},
+		"any":              {in: `print any([0, nil, false]); print any([nil, false]);`, want: "true\nfalse"},
+		"any fn":           {in: `print any([1, 2], x => x > 1);`, want: "true"},
+		"all":              {in: `print all([1, "a"]); print all([]); print all([1, nil]);`, want: "true\ntrue\nfalse"},
+		"all fn":           {in: `print all([1, 2], x => x > 1);`, want: "false"},
+		"all short":        {in: `var n = 0; all([1, 2, 3], x => (n += 1) < 2); print n;`, want: "2"},
+		"zip":              {in: `print zip([1, 2, 3], ["a", "b"]);`, want: `[[1, "a"], [2, "b"]]`},
+		"zip none":         {in: `print zip();`, want: "[]"},
+		"enumerate":        {in: `print enumerate(["a", "b"]);`, want: `[[0, "a"], [1, "b"]]`},
+		"sorted":           {in: `print sorted([3, 1.5, 2d, -1]);`, want: "[-1, 1.5, 2d, 3]"},
+		"sorted strings":   {in: `print sorted(["b", "C", "a"]);`, want: `["C", "a", "b"]`},
+		"sorted key":       {in: `print sorted(["ccc", "a", "bb"], len);`, want: `["a", "bb", "ccc"]`},
+		"sorted stable":    {in: `print sorted([[2, "a"], [1, "b"], [2, "c"], [1, "d"]], p => p[0]);`, want: `[[1, "b"], [1, "d"], [2, "a"], [2, "c"]]`},
+		"sorted copy":      {in: `var xs = [2, 1]; sorted(xs); print xs;`, want: "[2, 1]"},
+		"sorted mixed":     {in: `sorted([1, "a"]);`, wanterr: true},
+		"sorted nan":       {in: `sorted([1, math.nan]);`, wanterr: true},
+		"reverse":          {in: `print reverse([1, 2, 3]);`, want: "[3, 2, 1]"},
+		"sum":              {in: `print sum([1, 2, 3]); print sum([1, 0.5]); print sum([]);`, want: "6\n1.5\n0"},
+		"sum strings":      {in: `sum(["a"]);`, wanterr: true},
+		"min":              {in: `print min([3, 1, 2]);`, want: "1"},
+		"max key":          {in: `print max(["a", "ccc", "bb", "ddd"], len);`, want: "ccc"},
+		"min empty":        {in: `min([]);`, wanterr: true},
+		"closure":          {in: `var k = 10; print map([1], x => x + k);`, want: "[11]"},
+	}
+
+	runTable(t, table)
+}
+
+func TestCallbackErrors(t *testing.T) {
+	in := `var x = "outer";
+print sorted([2, 1], fn (n) {
+  var x = "inner";
+  return n + "oops";
+});
+print "unreachable";`
+
+	got, errs := interpretString(t, in)
+	if got != "" {
+		t.Errorf("interpreter kept running after error: %q", got)
+	}
+	if n := strings.Count(string(errs), "\n"); n != 1 {
+		t.Errorf("wanted one error, got %d: %q", n, errs)
+	}
+	if !strings.Contains(string(errs), "line 4") {
+		t.Errorf("error not reported inside callback: %q", errs)
+	}
+}
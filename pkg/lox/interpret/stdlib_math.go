@@ -0,0 +1,130 @@
+package interpret
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var ErrorNoArguments = errors.New("Expected at least one argument.")
+
+var mathNatives = []*Native{
+	{name: "floor", arity: 1, fn: nativeFloor},
+	{name: "ceil", arity: 1, fn: nativeCeil},
+	{name: "round", arity: 1, fn: nativeRound},
+	{name: "abs", arity: 1, fn: nativeAbs},
+	{name: "sqrt", arity: 1, fn: floatNative(math.Sqrt)},
+	{name: "sin", arity: 1, fn: floatNative(math.Sin)},
+	{name: "cos", arity: 1, fn: floatNative(math.Cos)},
+	{name: "tan", arity: 1, fn: floatNative(math.Tan)},
+	{name: "log", arity: 1, fn: floatNative(math.Log)},
+	{name: "pow", arity: 2, fn: nativePow},
+	{name: "min", arity: -1, fn: extremeNative(-1)},
+	{name: "max", arity: -1, fn: extremeNative(1)},
+	{name: "isNaN", arity: 1, fn: nativeIsNaN},
+}
+
+var mathConstants = map[string]interface{}{
+	"pi":  math.Pi,
+	"inf": math.Inf(1),
+	"nan": math.NaN(),
+}
+
+// floatNative wraps a function of one float.
+func floatNative(f func(float64) float64) func(*Interpreter, tok.Token, []interface{}) interface{} {
+	return func(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+		return f(toFloat(i.numberArg(paren, args, 0)))
+	}
+}
+
+// rounder rounds a number to an integer. Integers are returned unchanged, as
+// are floats that are infinite or NaN.
+func rounder(f func(float64) float64, r func(*big.Rat) *big.Int) func(*Interpreter, tok.Token, []interface{}) interface{} {
+	return func(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+		switch n := i.numberArg(paren, args, 0).(type) {
+		case float64:
+			if math.IsInf(n, 0) || math.IsNaN(n) {
+				return n
+			}
+			result, _ := new(big.Float).SetFloat64(f(n)).Int(nil)
+			return narrow(result)
+		case *big.Rat:
+			return narrow(r(n))
+		default:
+			return n
+		}
+	}
+}
+
+func floorRat(r *big.Rat) *big.Int {
+	// Integer division of big.Ints is Euclidean, which floors for positive
+	// denominators like those of a big.Rat.
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+func ceilRat(r *big.Rat) *big.Int {
+	return new(big.Int).Neg(floorRat(new(big.Rat).Neg(r)))
+}
+
+// roundRat rounds half away from zero, like math.Round.
+func roundRat(r *big.Rat) *big.Int {
+	half := big.NewRat(1, 2)
+	if r.Sign() < 0 {
+		return ceilRat(new(big.Rat).Sub(r, half))
+	}
+	return floorRat(new(big.Rat).Add(r, half))
+}
+
+var (
+	nativeFloor = rounder(math.Floor, floorRat)
+	nativeCeil  = rounder(math.Ceil, ceilRat)
+	nativeRound = rounder(math.Round, roundRat)
+)
+
+func nativeAbs(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	n := i.numberArg(paren, args, 0)
+	if c, ok := compare(n, int64(0)); ok && c < 0 {
+		return i.negate(paren, n)
+	}
+	return n
+}
+
+func nativePow(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	op := paren
+	op.Typ = tok.STAR_STAR
+	return i.arith(op, i.numberArg(paren, args, 0), i.numberArg(paren, args, 1))
+}
+
+// extremeNative finds the least (sign -1) or greatest (sign 1) of its
+// arguments. NaN arguments are ignored unless they are all NaN.
+func extremeNative(sign int) func(*Interpreter, tok.Token, []interface{}) interface{} {
+	return func(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+		if len(args) == 0 {
+			i.tracker.Fatal(errtrack.LoxError{
+				Message: ErrorNoArguments,
+				Token:   paren,
+			})
+		}
+
+		best := i.numberArg(paren, args, 0)
+		for j := range args[1:] {
+			n := i.numberArg(paren, args, j+1)
+			if c, ok := compare(n, best); (ok && c*sign > 0) || isNaN(best) {
+				best = n
+			}
+		}
+		return best
+	}
+}
+
+func isNaN(value interface{}) bool {
+	f, ok := value.(float64)
+	return ok && math.IsNaN(f)
+}
+
+func nativeIsNaN(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return isNaN(i.numberArg(paren, args, 0))
+}
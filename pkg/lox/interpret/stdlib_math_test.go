@@ -0,0 +1,42 @@
+package interpret
+
+import "testing"
+
+func TestMathNatives(t *testing.T) {
+	table := map[string]scriptTest{
+		"floor":           {in: `print math.floor(-2.5);`, want: "-3"},
+		"floor int":       {in: `print math.floor(7);`, want: "7"},
+		"floor decimal":   {in: `print math.floor(-1.5d);`, want: "-2"},
+		"ceil":            {in: `print math.ceil(2.1);`, want: "3"},
+		"ceil decimal":    {in: `print math.ceil(1/3d);`, want: "1"},
+		"round":           {in: `print math.round(2.5);`, want: "3"},
+		"round negative":  {in: `print math.round(-2.5d);`, want: "-3"},
+		"round inf":       {in: `print math.round(math.inf);`, want: "inf"},
+		"round huge":      {in: `print math.round(100000000000000000000.0);`, want: "100000000000000000000"},
+		"abs":             {in: `print math.abs(-3);`, want: "3"},
+		"abs float":       {in: `print math.abs(-0.5);`, want: "0.5"},
+		"abs decimal":     {in: `print math.abs(-1.10d);`, want: "1.1d"},
+		"sqrt":            {in: `print math.sqrt(16);`, want: "4.0"},
+		"sin":             {in: `print math.sin(0);`, want: "0.0"},
+		"cos":             {in: `print math.cos(0);`, want: "1.0"},
+		"tan":             {in: `print math.tan(0);`, want: "0.0"},
+		"log":             {in: `print math.log(1);`, want: "0.0"},
+		"pow":             {in: `print math.pow(2, 10);`, want: "1024"},
+		"pow float":       {in: `print math.pow(4, 0.5);`, want: "2.0"},
+		"min":             {in: `print math.min(3, 1.5, 2);`, want: "1.5"},
+		"max":             {in: `print math.max(3, 1.5, 2);`, want: "3"},
+		"max one":         {in: `print math.max(-1);`, want: "-1"},
+		"max nan":         {in: `print math.max(math.nan, 1);`, want: "1"},
+		"min none":        {in: `print math.min();`, wanterr: true},
+		"min string":      {in: `print math.min(1, "a");`, wanterr: true},
+		"pi":              {in: `print math.floor(math.pi * 100);`, want: "314"},
+		"inf":             {in: `print -math.inf;`, want: "-inf"},
+		"is nan":          {in: `print math.isNaN(math.nan);`, want: "true"},
+		"is not nan":      {in: `print math.isNaN(1);`, want: "false"},
+		"wrong type":      {in: `print math.sqrt("4");`, wanterr: true},
+		"unknown":         {in: `print math.tau;`, wanterr: true},
+		"clock is number": {in: `print clock() > 0;`, want: "true"},
+	}
+
+	runTable(t, table)
+}
@@ -0,0 +1,58 @@
+package interpret
+
+import (
+	"errors"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+var ErrorEmptyRange = errors.New("Range must not be empty.")
+
+// The random module draws from a source seeded by the interpreter, so that
+// deterministic runs are repeatable.
+var randomNatives = []*Native{
+	{name: "float", arity: 0, fn: nativeRandomFloat},
+	{name: "int", arity: 2, fn: nativeRandomInt},
+	{name: "choice", arity: 1, fn: nativeRandomChoice},
+	{name: "shuffled", arity: 1, fn: nativeRandomShuffled},
+}
+
+// float returns a number in [0, 1).
+func nativeRandomFloat(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return i.rand.Float64()
+}
+
+// int returns an integer between its arguments, inclusive.
+func nativeRandomInt(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	lo, hi := i.intArg(paren, args, 0), i.intArg(paren, args, 1)
+	if hi < lo || hi-lo+1 <= 0 {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorEmptyRange,
+			Token:   paren,
+		})
+	}
+	return lo + i.rand.Int63n(hi-lo+1)
+}
+
+func nativeRandomChoice(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	list := i.listArg(paren, args, 0)
+	if len(list.Elements) == 0 {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorEmptyList,
+			Token:   paren,
+		})
+	}
+	return list.Elements[i.rand.Intn(len(list.Elements))]
+}
+
+// shuffled returns a new list with the elements in random order.
+func nativeRandomShuffled(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	list := i.listArg(paren, args, 0)
+	i.allocList(len(list.Elements))
+	result := &List{Elements: append([]interface{}{}, list.Elements...)}
+	i.rand.Shuffle(len(result.Elements), func(a, b int) {
+		result.Elements[a], result.Elements[b] = result.Elements[b], result.Elements[a]
+	})
+	return result
+}
@@ -0,0 +1,74 @@
+package interpret
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRandomNatives(t *testing.T) {
+	table := map[string]scriptTest{
+		"float range":     {in: `var x = random.float(); print x >= 0 ? x < 1 : false;`, want: "true"},
+		"int range":       {in: `var x = random.int(1, 3); print x >= 1 ? x <= 3 : false;`, want: "true"},
+		"int single":      {in: `print random.int(7, 7);`, want: "7"},
+		"int empty":       {in: `print random.int(2, 1);`, wanterr: true},
+		"choice single":   {in: `print random.choice(["only"]);`, want: "only"},
+		"choice empty":    {in: `print random.choice([]);`, wanterr: true},
+		"shuffled sorted": {in: `print sorted(random.shuffled([3, 1, 2]));`, want: "[1, 2, 3]"},
+		"shuffled copies": {in: `var l = [1, 2]; random.shuffled(l); print l;`, want: "[1, 2]"},
+	}
+
+	runTable(t, table)
+}
+
+// interpretDeterministic runs in with the given seed in deterministic mode.
+func interpretDeterministic(t *testing.T, in string, seed int64) (string, []byte) {
+	t.Helper()
+	var out bytes.Buffer
+	fake := errtrack.NewFake()
+	ast := parse.New(fake.Tracker, scan.New(fake.Tracker, in).Tokens()).AST()
+	if fake.Tracker.HadError() {
+		t.Fatalf("could not parse %q: %s", in, fake.Errors())
+	}
+
+	New(fake.Tracker, Options{
+		Stdout:        &out,
+		Files:         ReadOnlyFS(fstest.MapFS{"a.txt": {Data: []byte("a")}}),
+		Deterministic: true,
+		Seed:          seed,
+	}).Interpret(ast)
+	return out.String(), fake.Errors()
+}
+
+func TestDeterministic(t *testing.T) {
+	const script = `
+print clock();
+print time.now();
+print random.float();
+print random.int(1, 1000000);
+print random.shuffled([1, 2, 3, 4, 5, 6, 7, 8]);
+print readLine();
+`
+	first, errs := interpretDeterministic(t, script, 1)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected error: %q", errs)
+	}
+	second, _ := interpretDeterministic(t, script, 1)
+	if diff := cmp.Diff(first, second); diff != "" {
+		t.Errorf("output differs between runs (-first,+second): %s", diff)
+	}
+	other, _ := interpretDeterministic(t, script, 2)
+	if first == other {
+		t.Errorf("different seeds gave the same output %q", first)
+	}
+
+	if _, errs := interpretDeterministic(t, `readFile("a.txt");`, 1); len(errs) == 0 {
+		t.Errorf("read a file in deterministic mode")
+	}
+}
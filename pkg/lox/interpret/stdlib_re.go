@@ -0,0 +1,152 @@
+package interpret
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Regex is a compiled regular expression in Go's RE2 syntax.
+type Regex struct {
+	re *regexp.Regexp
+}
+
+func (r *Regex) String() string {
+	return fmt.Sprintf("<regex %q>", r.re.String())
+}
+
+// Every re native takes either a compiled regex or a pattern string, which is
+// compiled on the spot. Matches are lists of the whole match followed by each
+// capture group, with nil for groups that did not take part.
+var reNatives = []*Native{
+	{name: "compile", arity: 1, fn: nativeReCompile},
+	{name: "match", arity: 2, fn: nativeReMatch},
+	{name: "find", arity: 2, fn: nativeReFind},
+	{name: "findAll", arity: 2, fn: nativeReFindAll},
+	{name: "groups", arity: 2, fn: nativeReGroups},
+	{name: "replace", arity: 3, fn: nativeReReplace},
+	{name: "split", arity: 2, fn: nativeReSplit},
+}
+
+func (i *Interpreter) regexArg(paren tok.Token, args []interface{}, n int) *regexp.Regexp {
+	if r, ok := args[n].(*Regex); ok {
+		return r.re
+	}
+
+	pattern, ok := args[n].(string)
+	if !ok {
+		i.argError(paren, n, "a regex or string")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Invalid regex: %v.", err),
+			Token:   paren,
+		})
+	}
+	return re
+}
+
+func nativeReCompile(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return &Regex{i.regexArg(paren, args, 0)}
+}
+
+// match reports whether the regex matches anywhere in the string.
+func nativeReMatch(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return i.regexArg(paren, args, 0).MatchString(i.stringArg(paren, args, 1))
+}
+
+// matchList converts submatch indices into a list of strings.
+func matchList(s string, loc []int) *List {
+	list := &List{Elements: make([]interface{}, len(loc)/2)}
+	for j := range list.Elements {
+		if loc[2*j] >= 0 {
+			list.Elements[j] = s[loc[2*j]:loc[2*j+1]]
+		}
+	}
+	return list
+}
+
+// find returns the first match, or nil.
+func nativeReFind(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	re, s := i.regexArg(paren, args, 0), i.stringArg(paren, args, 1)
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return nil
+	}
+	return matchList(s, loc)
+}
+
+// findAll lists every non-overlapping match.
+func nativeReFindAll(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	re, s := i.regexArg(paren, args, 0), i.stringArg(paren, args, 1)
+	all := re.FindAllStringSubmatchIndex(s, -1)
+	i.allocList(len(all))
+	i.allocN(int64(len(all)), objectSize+slotSize*int64(re.NumSubexp()+1))
+	list := &List{Elements: make([]interface{}, len(all))}
+	for j, loc := range all {
+		list.Elements[j] = matchList(s, loc)
+	}
+	return list
+}
+
+// groups returns a map from the names of the named groups in the first match
+// to their text, or nil if there is no match.
+func nativeReGroups(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	re, s := i.regexArg(paren, args, 0), i.stringArg(paren, args, 1)
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return nil
+	}
+
+	match := matchList(s, loc)
+	groups := NewMap()
+	for j, name := range re.SubexpNames() {
+		if name != "" {
+			groups.Set(name, match.Elements[j])
+		}
+	}
+	return groups
+}
+
+// replace replaces every match. The replacement is either a string, in which
+// $1 or $name stand for groups, or a function called with each match that
+// returns its replacement. Go's ${name} form would be interpolated by Lox.
+func nativeReReplace(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	re, s := i.regexArg(paren, args, 0), i.stringArg(paren, args, 1)
+	template, isString := args[2].(string)
+	if _, ok := args[2].(Callable); !ok && !isString {
+		i.argError(paren, 2, "a string or function")
+	}
+
+	// Build the result one match at a time, so that memory is accounted for
+	// before many large replacements can add up.
+	var out []byte
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		var repl []byte
+		if isString {
+			repl = re.ExpandString(nil, template, s, loc)
+		} else {
+			repl = []byte(Stringify(i.call(paren, args[2], []interface{}{matchList(s, loc)})))
+		}
+		i.alloc(int64(loc[0] - last + len(repl)))
+		out = append(out, s[last:loc[0]]...)
+		out = append(out, repl...)
+		last = loc[1]
+	}
+	i.alloc(int64(len(s) - last))
+	return string(append(out, s[last:]...))
+}
+
+func nativeReSplit(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	parts := i.regexArg(paren, args, 0).Split(i.stringArg(paren, args, 1), -1)
+	i.allocList(len(parts))
+	list := &List{Elements: make([]interface{}, len(parts))}
+	for j := range parts {
+		list.Elements[j] = parts[j]
+	}
+	return list
+}
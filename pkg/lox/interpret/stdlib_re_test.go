@@ -0,0 +1,29 @@
+package interpret
+
+import "testing"
+
+func TestRegexNatives(t *testing.T) {
+	table := map[string]scriptTest{
+		"compile":         {in: `print re.compile("a+b");`, want: `<regex "a+b">`},
+		"compile invalid": {in: `re.compile("(");`, wanterr: true},
+		"compile type":    {in: `re.compile(1);`, wanterr: true},
+		"match":           {in: `print re.match("\d+", "abc123");`, want: "true"},
+		"no match":        {in: `print re.match("^\d+$", "abc123");`, want: "false"},
+		"match compiled":  {in: `var r = re.compile("b"); print re.match(r, "abc");`, want: "true"},
+		"find":            {in: `print re.find("(\w+)@(\w+)", "mail bob@example now");`, want: `["bob@example", "bob", "example"]`},
+		"find optional":   {in: `print re.find("a(x)?", "a");`, want: `["a", nil]`},
+		"find none":       {in: `print re.find("z", "abc");`, want: "nil"},
+		"find all":        {in: `print re.findAll("(\d)(\w)", "1a 2b 3");`, want: `[["1a", "1", "a"], ["2b", "2", "b"]]`},
+		"find all none":   {in: `print re.findAll("z", "abc");`, want: "[]"},
+		"groups":          {in: `print re.groups("(?P<key>\w+)=(?P<val>\w*)", "x a=1");`, want: `{"key": "a", "val": "1"}`},
+		"groups none":     {in: `print re.groups("(?P<key>\w+)=", "nothing");`, want: "nil"},
+		"replace":         {in: `print re.replace("(\w+)@(\w+)", "bob@home", "$2:$1");`, want: "home:bob"},
+		"replace fn":      {in: `print re.replace("\d+", "a1b22", fn (m) { return "<${len(m[0])}>"; });`, want: "a<1>b<2>"},
+		"replace arrow":   {in: `print re.replace("[aeiou]", "banana", m => upper(m[0]));`, want: "bAnAnA"},
+		"replace arity":   {in: `re.replace("a", "a", fn () { return ""; });`, wanterr: true},
+		"replace type":    {in: `re.replace("a", "a", 1);`, wanterr: true},
+		"split":           {in: `print re.split(",\s*", "a, b,c");`, want: `["a", "b", "c"]`},
+	}
+
+	runTable(t, table)
+}
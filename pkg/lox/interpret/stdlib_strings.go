@@ -0,0 +1,217 @@
+package interpret
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// String natives count positions in code points rather than bytes, the same
+// way the scanner reads source code.
+
+var (
+	ErrorNoLength      = errors.New("Argument 1 must be a string, list or map.")
+	ErrorBounds        = errors.New("Substring bounds out of range.")
+	ErrorNegativeCount = errors.New("Count must not be negative.")
+	ErrorFormatArgs    = errors.New("Format placeholders do not match arguments.")
+	ErrorTooLong       = errors.New("Result would be too long.")
+)
+
+// maxRepeat is the length in bytes of the longest string repeat makes, whatever
+// the memory limit.
+const maxRepeat = 1 << 30
+
+var stringNatives = []*Native{
+	{name: "len", arity: 1, fn: nativeLen},
+	{name: "upper", arity: 1, fn: nativeUpper},
+	{name: "lower", arity: 1, fn: nativeLower},
+	{name: "trim", arity: 1, fn: nativeTrim},
+	{name: "split", arity: 2, fn: nativeSplit},
+	{name: "join", arity: 2, fn: nativeJoin},
+	{name: "replace", arity: 3, fn: nativeReplace},
+	{name: "contains", arity: 2, fn: nativeContains},
+	{name: "startsWith", arity: 2, fn: nativeStartsWith},
+	{name: "endsWith", arity: 2, fn: nativeEndsWith},
+	{name: "indexOf", arity: 2, fn: nativeIndexOf},
+	{name: "substring", arity: 3, fn: nativeSubstring},
+	{name: "repeat", arity: 2, fn: nativeRepeat},
+	{name: "runes", arity: 1, fn: nativeRunes},
+	{name: "format", arity: -1, fn: nativeFormat},
+}
+
+func nativeLen(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	switch v := args[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v))
+	case *List:
+		return int64(len(v.Elements))
+	case *Map:
+		return int64(v.Len())
+	}
+
+	i.tracker.Fatal(errtrack.LoxError{
+		Message: ErrorNoLength,
+		Token:   paren,
+	})
+	return nil // unreachable
+}
+
+func nativeUpper(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	s := i.stringArg(paren, args, 0)
+	i.alloc(int64(len(s)))
+	return strings.ToUpper(s)
+}
+
+func nativeLower(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	s := i.stringArg(paren, args, 0)
+	i.alloc(int64(len(s)))
+	return strings.ToLower(s)
+}
+
+func nativeTrim(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return strings.TrimSpace(i.stringArg(paren, args, 0))
+}
+
+// split separates a string around each instance of a separator. An empty
+// separator splits between every code point.
+func nativeSplit(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	s, sep := i.stringArg(paren, args, 0), i.stringArg(paren, args, 1)
+	i.allocList(strings.Count(s, sep) + 1)
+	parts := strings.Split(s, sep)
+	list := &List{Elements: make([]interface{}, len(parts))}
+	for j := range parts {
+		list.Elements[j] = parts[j]
+	}
+	return list
+}
+
+// join concatenates the elements of a list, stringifying any that are not
+// strings.
+func nativeJoin(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	list, sep := i.listArg(paren, args, 0), i.stringArg(paren, args, 1)
+	parts := make([]string, len(list.Elements))
+	size := len(sep) * len(parts)
+	for j, el := range list.Elements {
+		parts[j] = Stringify(el)
+		size += len(parts[j])
+	}
+	i.alloc(int64(size))
+	return strings.Join(parts, sep)
+}
+
+func nativeReplace(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	s, old, repl := i.stringArg(paren, args, 0), i.stringArg(paren, args, 1), i.stringArg(paren, args, 2)
+	i.allocN(int64(strings.Count(s, old)), int64(len(repl)))
+	i.alloc(int64(len(s)))
+	return strings.ReplaceAll(s, old, repl)
+}
+
+func nativeContains(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return strings.Contains(i.stringArg(paren, args, 0), i.stringArg(paren, args, 1))
+}
+
+func nativeStartsWith(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return strings.HasPrefix(i.stringArg(paren, args, 0), i.stringArg(paren, args, 1))
+}
+
+func nativeEndsWith(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return strings.HasSuffix(i.stringArg(paren, args, 0), i.stringArg(paren, args, 1))
+}
+
+// indexOf finds the code point position of a substring, or -1.
+func nativeIndexOf(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	s := i.stringArg(paren, args, 0)
+	idx := strings.Index(s, i.stringArg(paren, args, 1))
+	if idx < 0 {
+		return int64(-1)
+	}
+	return int64(utf8.RuneCountInString(s[:idx]))
+}
+
+// substring slices a string from the start code point up to, but not
+// including, the end code point.
+func nativeSubstring(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	runes := []rune(i.stringArg(paren, args, 0))
+	start, end := i.intArg(paren, args, 1), i.intArg(paren, args, 2)
+	if start < 0 || end < start || end > int64(len(runes)) {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorBounds,
+			Token:   paren,
+		})
+	}
+	return string(runes[start:end])
+}
+
+func nativeRepeat(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	s, n := i.stringArg(paren, args, 0), i.intArg(paren, args, 1)
+	if n < 0 {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorNegativeCount,
+			Token:   paren,
+		})
+	}
+	i.allocN(n, int64(len(s)))
+	if len(s) > 0 && n > maxRepeat/int64(len(s)) {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorTooLong,
+			Token:   paren,
+		})
+	}
+	return strings.Repeat(s, int(n))
+}
+
+// runes lists the code points of a string, each as a string of its own.
+func nativeRunes(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	s := i.stringArg(paren, args, 0)
+	n := utf8.RuneCountInString(s)
+	i.allocList(n)
+	i.allocN(int64(n), utf8.UTFMax)
+	list := &List{Elements: make([]interface{}, 0, n)}
+	for _, r := range s {
+		list.Elements = append(list.Elements, string(r))
+	}
+	return list
+}
+
+// format replaces each {} in the format string with the next argument. Literal
+// braces are written {{ and }}.
+func nativeFormat(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	if len(args) == 0 {
+		i.argError(paren, 0, "a string")
+	}
+	format, args := i.stringArg(paren, args, 0), args[1:]
+
+	var b strings.Builder
+	for j := 0; j < len(format); j++ {
+		c := format[j]
+		switch {
+		case c == '{' && j+1 < len(format) && format[j+1] == '}':
+			if len(args) == 0 {
+				i.tracker.Fatal(errtrack.LoxError{
+					Message: ErrorFormatArgs,
+					Token:   paren,
+				})
+			}
+			b.WriteString(Stringify(args[0]))
+			args = args[1:]
+			j++
+		case (c == '{' || c == '}') && j+1 < len(format) && format[j+1] == c:
+			b.WriteByte(c)
+			j++
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	if len(args) != 0 {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: ErrorFormatArgs,
+			Token:   paren,
+		})
+	}
+	i.alloc(int64(b.Len()))
+	return b.String()
+}
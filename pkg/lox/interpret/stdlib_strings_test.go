@@ -0,0 +1,44 @@
+package interpret
+
+import "testing"
+
+func TestStringNatives(t *testing.T) {
+	table := map[string]scriptTest{
+		"len":                {in: `print len("héllo");`, want: "5"},
+		"len list":           {in: `print len([1, 2]);`, want: "2"},
+		"len number":         {in: `print len(1);`, wanterr: true},
+		"upper":              {in: `print upper("héllo");`, want: "HÉLLO"},
+		"lower":              {in: `print lower("ÀB");`, want: "àb"},
+		"trim":               {in: `print "[" + trim("  x y ") + "]";`, want: "[x y]"},
+		"split":              {in: `print split("a,b,,c", ",");`, want: `["a", "b", "", "c"]`},
+		"split runes":        {in: `print split("日本", "");`, want: `["日", "本"]`},
+		"join":               {in: `print join(["a", 1, nil], ", ");`, want: "a, 1, nil"},
+		"join non-list":      {in: `print join("abc", ", ");`, wanterr: true},
+		"replace":            {in: `print replace("a.b.c", ".", "::");`, want: "a::b::c"},
+		"contains":           {in: `print contains("hello", "ll");`, want: "true"},
+		"starts with":        {in: `print startsWith("hello", "he");`, want: "true"},
+		"ends with":          {in: `print endsWith("hello", "he");`, want: "false"},
+		"index of":           {in: `print indexOf("日本語", "語");`, want: "2"},
+		"index of missing":   {in: `print indexOf("abc", "z");`, want: "-1"},
+		"substring":          {in: `print substring("héllo", 1, 3);`, want: "él"},
+		"substring empty":    {in: `print substring("abc", 3, 3);`, want: ""},
+		"substring range":    {in: `print substring("abc", 2, 4);`, wanterr: true},
+		"substring reversed": {in: `print substring("abc", 2, 1);`, wanterr: true},
+		"repeat":             {in: `print repeat("ab", 3);`, want: "ababab"},
+		"repeat negative":    {in: `print repeat("ab", -1);`, wanterr: true},
+		"repeat huge":        {in: `print repeat("ab", 4000000000000000000);`, wanterr: true},
+		"repeat empty huge":  {in: `print len(repeat("", 4000000000000000000));`, want: "0"},
+		"runes":              {in: `print runes("añ");`, want: `["a", "ñ"]`},
+		"format":             {in: `print format("{} + {} = {}", 1, 2, 3);`, want: "1 + 2 = 3"},
+		"format escapes":     {in: `print format("{{}} {}", "x");`, want: "{} x"},
+		"format too few":     {in: `print format("{} {}", 1);`, wanterr: true},
+		"format too many":    {in: `print format("{}", 1, 2);`, wanterr: true},
+		"format no args":     {in: `print format();`, wanterr: true},
+		"wrong type":         {in: `print upper(1);`, wanterr: true},
+		"wrong arity":        {in: `print upper("a", "b");`, wanterr: true},
+		"shadow native":      {in: `var len = 1; print len;`, want: "1"},
+		"native in module":   {in: `fn f() { return len("ab"); } print f();`, want: "2"},
+	}
+
+	runTable(t, table)
+}
@@ -0,0 +1,135 @@
+package interpret
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Time is an instant, as returned by time.now.
+type Time struct {
+	t time.Time
+}
+
+func (t Time) String() string {
+	return t.t.Format(time.RFC3339Nano)
+}
+
+// Duration is the span between two instants.
+type Duration struct {
+	d time.Duration
+}
+
+func (d Duration) String() string {
+	return d.d.String()
+}
+
+var clockNatives = []*Native{
+	{name: "clock", arity: 0, fn: nativeClock},
+}
+
+// Layouts for time.format and time.parse are Go reference layouts.
+var timeNatives = []*Native{
+	{name: "now", arity: 0, fn: nativeNow},
+	{name: "unix", arity: 1, fn: nativeUnix},
+	{name: "format", arity: 2, fn: nativeTimeFormat},
+	{name: "parse", arity: 2, fn: nativeTimeParse},
+	{name: "duration", arity: 1, fn: nativeDuration},
+	{name: "add", arity: 2, fn: nativeTimeAdd},
+	{name: "since", arity: 1, fn: nativeSince},
+	{name: "between", arity: 2, fn: nativeBetween},
+	{name: "seconds", arity: 1, fn: nativeSeconds},
+}
+
+var timeConstants = map[string]interface{}{
+	"iso":  time.RFC3339,
+	"date": "2006-01-02",
+}
+
+// clock returns the seconds since the Unix epoch, as in the book.
+func nativeClock(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return float64(i.clock().UnixNano()) / float64(time.Second)
+}
+
+func (i *Interpreter) timeArg(paren tok.Token, args []interface{}, n int) time.Time {
+	t, ok := args[n].(Time)
+	if !ok {
+		i.argError(paren, n, "a time")
+	}
+	return t.t
+}
+
+func (i *Interpreter) durationArg(paren tok.Token, args []interface{}, n int) time.Duration {
+	d, ok := args[n].(Duration)
+	if !ok {
+		i.argError(paren, n, "a duration")
+	}
+	return d.d
+}
+
+func nativeNow(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return Time{i.clock()}
+}
+
+// unix converts seconds since the Unix epoch to a time in UTC.
+func nativeUnix(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	secs := toFloat(i.numberArg(paren, args, 0))
+	return Time{time.Unix(0, int64(secs*float64(time.Second))).UTC()}
+}
+
+func nativeTimeFormat(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return i.timeArg(paren, args, 0).Format(i.stringArg(paren, args, 1))
+}
+
+func nativeTimeParse(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	t, err := time.Parse(i.stringArg(paren, args, 1), i.stringArg(paren, args, 0))
+	if err != nil {
+		i.tracker.Fatal(errtrack.LoxError{
+			Message: fmt.Errorf("Cannot parse time: %v.", err),
+			Token:   paren,
+		})
+	}
+	return Time{t}
+}
+
+// duration makes a duration from a number of seconds or from a string such as
+// "1h30m".
+func nativeDuration(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	if s, ok := args[0].(string); ok {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			i.tracker.Fatal(errtrack.LoxError{
+				Message: fmt.Errorf("Cannot parse duration: %v.", err),
+				Token:   paren,
+			})
+		}
+		return Duration{d}
+	}
+
+	secs := toFloat(i.numberArg(paren, args, 0))
+	return Duration{time.Duration(secs * float64(time.Second))}
+}
+
+func nativeTimeAdd(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return Time{i.timeArg(paren, args, 0).Add(i.durationArg(paren, args, 1))}
+}
+
+func nativeSince(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return Duration{i.clock().Sub(i.timeArg(paren, args, 0))}
+}
+
+// between is the duration from the first time to the second.
+func nativeBetween(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	return Duration{i.timeArg(paren, args, 1).Sub(i.timeArg(paren, args, 0))}
+}
+
+// seconds converts a duration to a number of seconds, or a time to seconds
+// since the Unix epoch.
+func nativeSeconds(i *Interpreter, paren tok.Token, args []interface{}) interface{} {
+	if t, ok := args[0].(Time); ok {
+		return float64(t.t.UnixNano()) / float64(time.Second)
+	}
+	return i.durationArg(paren, args, 0).Seconds()
+}
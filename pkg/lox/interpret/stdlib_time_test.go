@@ -0,0 +1,37 @@
+package interpret
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeNatives(t *testing.T) {
+	now := time.Date(2021, time.March, 14, 15, 9, 26, 500000000, time.UTC)
+	fixed := func(i *Interpreter) {
+		i.SetClock(func() time.Time { return now })
+	}
+
+	table := map[string]scriptTest{
+		"clock":            {in: `print clock();`, want: "1615734566.5"},
+		"now":              {in: `print time.now();`, want: "2021-03-14T15:09:26.5Z"},
+		"now equal":        {in: `print time.now() == time.now();`, want: "true"},
+		"unix":             {in: `print time.unix(0);`, want: "1970-01-01T00:00:00Z"},
+		"format":           {in: `print time.format(time.now(), time.date);`, want: "2021-03-14"},
+		"format layout":    {in: `print time.format(time.now(), "15:04");`, want: "15:09"},
+		"parse":            {in: `print time.parse("2000-01-02", time.date);`, want: "2000-01-02T00:00:00Z"},
+		"parse iso":        {in: `print time.parse("2000-01-02T03:04:05Z", time.iso) == time.unix(946782245);`, want: "true"},
+		"parse bad":        {in: `print time.parse("yesterday", time.date);`, wanterr: true},
+		"duration string":  {in: `print time.duration("1h30m");`, want: "1h30m0s"},
+		"duration seconds": {in: `print time.duration(1.5);`, want: "1.5s"},
+		"duration bad":     {in: `print time.duration("soon");`, wanterr: true},
+		"add":              {in: `print time.add(time.unix(0), time.duration("24h"));`, want: "1970-01-02T00:00:00Z"},
+		"add non-duration": {in: `print time.add(time.now(), 5);`, wanterr: true},
+		"since":            {in: `print time.since(time.unix(1615734560));`, want: "6.5s"},
+		"between":          {in: `print time.between(time.unix(0), time.unix(60));`, want: "1m0s"},
+		"seconds":          {in: `print time.seconds(time.duration("2m"));`, want: "120.0"},
+		"seconds time":     {in: `print time.seconds(time.now());`, want: "1615734566.5"},
+		"seconds other":    {in: `print time.seconds("2m");`, wanterr: true},
+	}
+
+	runTable(t, table, fixed)
+}
@@ -0,0 +1,221 @@
+package lsp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/resolve"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// document is an open file, analyzed each time it changes.
+type document struct {
+	uri    string
+	text   string
+	lines  []string
+	stmts  []stmt.Type
+	parser *parse.Parser
+	info   *resolve.Info
+	errors []errtrack.LoxError
+}
+
+func newDocument(uri, text string, builtins []string) *document {
+	tracker := errtrack.NewWithOutput(ioutil.Discard)
+	parser := parse.New(tracker, scan.New(tracker, text).Tokens())
+	stmts := parser.AST()
+	return &document{
+		uri:    uri,
+		text:   text,
+		lines:  strings.Split(text, "\n"),
+		stmts:  stmts,
+		parser: parser,
+		info:   resolve.Resolve(stmts, parser, builtins),
+		errors: tracker.Errors(),
+	}
+}
+
+// diagnostics are the document's syntax errors, or if it has none, its
+// undefined names.
+func (d *document) diagnostics() []Diagnostic {
+	diags := []Diagnostic{}
+	for _, err := range d.errors {
+		diags = append(diags, Diagnostic{
+			Range:    d.tokenRange(err.Token),
+			Severity: SeverityError,
+			Source:   "ilox",
+			Message:  err.Message.Error(),
+		})
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+	for _, use := range d.info.Unresolved {
+		diags = append(diags, Diagnostic{
+			Range:    d.tokenRange(use),
+			Severity: SeverityWarning,
+			Source:   "ilox",
+			Message:  errtrack.ErrorUndefined(use).Message.Error(),
+		})
+	}
+	return diags
+}
+
+// line returns the text of a zero based line, without its line ending.
+func (d *document) line(n int) string {
+	if n < 0 || n >= len(d.lines) {
+		return ""
+	}
+	return strings.TrimSuffix(d.lines[n], "\r")
+}
+
+// position converts the position of a token to the protocol's.
+func (d *document) position(t tok.Token) Position {
+	// The scanner counts columns from 1 on the first line and from 2 on the
+	// rest, in bytes.
+	col := t.Char - 1
+	if t.Line > 1 {
+		col--
+	}
+	text := d.line(t.Line - 1)
+	if col < 0 {
+		col = 0
+	} else if col > len(text) {
+		col = len(text)
+	}
+	return Position{Line: t.Line - 1, Character: utf16Len(text[:col])}
+}
+
+// tokenRange is the range of a token's lexeme, up to the end of its line.
+func (d *document) tokenRange(t tok.Token) Range {
+	start := d.position(t)
+	lexeme := t.Lexeme
+	if nl := strings.IndexByte(lexeme, '\n'); nl >= 0 {
+		lexeme = lexeme[:nl]
+	}
+	end := start
+	end.Character += utf16Len(lexeme)
+	return Range{Start: start, End: end}
+}
+
+// spanRange is the range from the start of a span to the end of its last
+// token.
+func (d *document) spanRange(span parse.Span) Range {
+	return Range{Start: d.position(span.Start), End: d.tokenRange(span.End).End}
+}
+
+// scannerPosition converts a position to a line and column as the scanner
+// counts them.
+func (d *document) scannerPosition(p Position) (line, char int) {
+	text := d.line(p.Line)
+	units, col := 0, 0
+	for col < len(text) && units < p.Character {
+		r, size := utf8.DecodeRuneInString(text[col:])
+		units += utf16Units(r)
+		col += size
+	}
+	char = col + 1
+	if p.Line > 0 {
+		char++
+	}
+	return p.Line + 1, char
+}
+
+// end is the position after the last character.
+func (d *document) end() Position {
+	last := len(d.lines) - 1
+	return Position{Line: last, Character: utf16Len(d.lines[last])}
+}
+
+func (d *document) location(t tok.Token) Location {
+	return Location{URI: d.uri, Range: d.tokenRange(t)}
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16Units(r)
+	}
+	return n
+}
+
+// utf16Units is the number of UTF-16 code units that encode r.
+func utf16Units(r rune) int {
+	if r >= 0x10000 {
+		return 2 // a surrogate pair
+	}
+	return 1
+}
+
+// symbolAt finds the symbol named at a position.
+func (d *document) symbolAt(p Position) (*resolve.Symbol, tok.Token, bool) {
+	line, char := d.scannerPosition(p)
+	sym, name, ok := d.info.At(line, char)
+	if !ok && char > 1 {
+		// The cursor may be just after the name.
+		sym, name, ok = d.info.At(line, char-1)
+	}
+	return sym, name, ok && sym != nil
+}
+
+func params(toks []tok.Token) string {
+	names := make([]string, len(toks))
+	for j := range toks {
+		names[j] = toks[j].Lexeme
+	}
+	return strings.Join(names, ", ")
+}
+
+// signature shows how a symbol was declared, as Lox.
+func signature(sym *resolve.Symbol) string {
+	switch node := sym.Node.(type) {
+	case *stmt.Function:
+		if sym.Kind == resolve.Function {
+			return fmt.Sprintf("fn %s(%s)", node.Name.Lexeme, params(node.Params))
+		}
+	case *stmt.Var:
+		return "var " + sym.Name
+	case *stmt.Import:
+		if node.Names == nil {
+			return fmt.Sprintf("import %s as %s", node.Path.Lexeme, sym.Name)
+		}
+		return fmt.Sprintf("from %s import %s", node.Path.Lexeme, sym.Name)
+	}
+	return sym.Name
+}
+
+// describe says what kind of name a symbol is and where it is in scope.
+func describe(info *resolve.Info, sym *resolve.Symbol) string {
+	desc := sym.Kind.String()
+	switch sym.Kind {
+	case resolve.Builtin:
+		return desc
+	case resolve.Param:
+		desc += " of " + owner(info, sym.Scope)
+	case resolve.Local, resolve.Function, resolve.Import:
+		if sym.Scope != info.Top {
+			desc += " in " + owner(info, sym.Scope)
+		}
+	}
+	return fmt.Sprintf("%s, declared on line %d", desc, sym.Decl.Line)
+}
+
+// owner names the function a scope is part of.
+func owner(info *resolve.Info, scope *resolve.Scope) string {
+	for ; scope != nil && scope != info.Top; scope = scope.Parent {
+		switch fn := scope.Owner.(type) {
+		case *stmt.Function:
+			return "fn " + fn.Name.Lexeme
+		case nil:
+			continue
+		default:
+			return "a lambda"
+		}
+	}
+	return "a block"
+}
@@ -0,0 +1,391 @@
+// Package lsp is a Language Server Protocol server for Lox, so editors can show
+// errors, jump to definitions, complete names and format code.
+//
+// The server analyzes each open document on its own: imported modules are not
+// read, so names imported from them are known but not their declarations.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/format"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/resolve"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/wire"
+)
+
+// ErrNoShutdown means the client exited or hung up without asking the server
+// to shut down first.
+var ErrNoShutdown = errors.New("lsp: exit without shutdown")
+
+// Server serves one client.
+type Server struct {
+	docs     map[string]*document
+	builtins []string
+	out      *wire.Writer
+	shutdown bool
+	exited   bool
+}
+
+func NewServer() *Server {
+	return &Server{
+		docs:     make(map[string]*document),
+		builtins: interpret.Builtins(),
+	}
+}
+
+// handlers answer requests and notifications by method. The result of a
+// notification is ignored.
+var handlers = map[string]func(s *Server, params json.RawMessage) (interface{}, error){
+	"initialize":                  (*Server).initialize,
+	"initialized":                 ignore,
+	"shutdown":                    (*Server).shutdownRequest,
+	"exit":                        (*Server).exit,
+	"textDocument/didOpen":        (*Server).didOpen,
+	"textDocument/didChange":      (*Server).didChange,
+	"textDocument/didClose":       (*Server).didClose,
+	"textDocument/definition":     (*Server).definition,
+	"textDocument/references":     (*Server).references,
+	"textDocument/hover":          (*Server).hover,
+	"textDocument/documentSymbol": (*Server).documentSymbol,
+	"textDocument/completion":     (*Server).completion,
+	"textDocument/formatting":     (*Server).formatting,
+}
+
+func ignore(*Server, json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+// Serve reads messages from r and writes replies to w until the client exits.
+// It returns ErrNoShutdown if the client did not shut the server down first.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	in := wire.NewReader(r)
+	s.out = wire.NewWriter(w)
+	for !s.exited {
+		body, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := s.handle(body); err != nil {
+			return err
+		}
+	}
+	if !s.shutdown {
+		return ErrNoShutdown
+	}
+	return nil
+}
+
+// handle dispatches one message and replies to it if it is a request.
+func (s *Server) handle(body []byte) error {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return s.reply(json.RawMessage("null"), nil, &Error{Code: CodeParseError, Message: err.Error()})
+	}
+
+	handler, ok := handlers[req.Method]
+	var result interface{}
+	var err error
+	switch {
+	case s.shutdown && req.Method != "exit":
+		err = &Error{Code: CodeInvalidRequest, Message: "server is shut down"}
+	case !ok:
+		err = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+	default:
+		result, err = handler(s, req.Params)
+	}
+
+	if req.ID == nil {
+		return nil // notifications have no reply, even for errors
+	}
+	return s.reply(req.ID, result, err)
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}, err error) error {
+	if err == nil {
+		return s.out.Write(response{JSONRPC: "2.0", ID: id, Result: result})
+	}
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	return s.out.Write(errorResponse{JSONRPC: "2.0", ID: id, Error: rpcErr})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.out.Write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func unmarshal(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":           1, // full
+			"definitionProvider":         true,
+			"referencesProvider":         true,
+			"hoverProvider":              true,
+			"documentSymbolProvider":     true,
+			"completionProvider":         map[string]interface{}{},
+			"documentFormattingProvider": true,
+		},
+		"serverInfo": map[string]interface{}{"name": "ilox"},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) exit(json.RawMessage) (interface{}, error) {
+	s.exited = true
+	return nil, nil
+}
+
+// open analyzes a document and publishes its diagnostics.
+func (s *Server) open(uri, text string) error {
+	doc := newDocument(uri, text, s.builtins)
+	s.docs[uri] = doc
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: doc.diagnostics(),
+	})
+}
+
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	return nil, s.open(p.TextDocument.URI, p.TextDocument.Text)
+}
+
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeParams
+	if err := unmarshal(params, &p); err != nil || len(p.ContentChanges) == 0 {
+		return nil, err
+	}
+	return nil, s.open(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+}
+
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	delete(s.docs, p.TextDocument.URI)
+	return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// symbolAt finds the document and symbol at a position. Requests about
+// documents that are not open, or positions without a name, find nothing.
+func (s *Server) symbolAt(p TextDocumentPositionParams) (*document, *resolve.Symbol, bool) {
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil, false
+	}
+	sym, _, ok := doc.symbolAt(p.Position)
+	return doc, sym, ok
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, sym, ok := s.symbolAt(p)
+	if !ok || sym.Kind == resolve.Builtin {
+		return nil, nil
+	}
+	return []Location{doc.location(sym.Decl)}, nil
+}
+
+func (s *Server) references(params json.RawMessage) (interface{}, error) {
+	var p ReferenceParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, sym, ok := s.symbolAt(p.TextDocumentPositionParams)
+	if !ok {
+		return nil, nil
+	}
+	locs := []Location{}
+	if p.Context.IncludeDeclaration && sym.Kind != resolve.Builtin {
+		locs = append(locs, doc.location(sym.Decl))
+	}
+	for _, ref := range sym.Refs {
+		locs = append(locs, doc.location(ref))
+	}
+	return locs, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	sym, name, ok := doc.symbolAt(p.Position)
+	if !ok {
+		return nil, nil
+	}
+	return Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("```lox\n%s\n```\n%s", signature(sym), describe(doc.info, sym)),
+		},
+		Range: doc.tokenRange(name),
+	}, nil
+}
+
+func (s *Server) completion(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+
+	items := []CompletionItem{}
+	for _, sym := range doc.info.Visible(doc.scannerPosition(p.Position)) {
+		kind := CompletionVariable
+		switch sym.Kind {
+		case resolve.Function:
+			kind = CompletionFunction
+		case resolve.Import:
+			kind = CompletionModule
+		}
+		items = append(items, CompletionItem{Label: sym.Name, Kind: kind, Detail: signature(sym)})
+	}
+
+	keywords := make([]string, 0, len(scan.RESERVED))
+	for word := range scan.RESERVED {
+		keywords = append(keywords, word)
+	}
+	sort.Strings(keywords)
+	for _, word := range keywords {
+		items = append(items, CompletionItem{Label: word, Kind: CompletionKeyword})
+	}
+	return items, nil
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	return doc.symbols(doc.stmts, true), nil
+}
+
+// symbols outlines the functions among stmts, and their variables and imports
+// if they are at the top level.
+func (d *document) symbols(stmts []stmt.Type, top bool) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, s := range stmts {
+		span, _ := d.parser.Span(s)
+		if export, ok := s.(*stmt.Export); ok {
+			s = export.Decl
+		}
+
+		switch s := s.(type) {
+		case *stmt.Function:
+			symbols = append(symbols, DocumentSymbol{
+				Name:           s.Name.Lexeme,
+				Detail:         "fn(" + params(s.Params) + ")",
+				Kind:           SymbolFunction,
+				Range:          d.spanRange(span),
+				SelectionRange: d.tokenRange(s.Name),
+				Children:       d.symbols(s.Body, false),
+			})
+		case *stmt.Var:
+			lambda, isFn := s.Initializer.(*expr.Lambda)
+			if !top && !isFn {
+				continue
+			}
+			sym := DocumentSymbol{
+				Name:           s.Name.Lexeme,
+				Kind:           SymbolVariable,
+				Range:          d.spanRange(span),
+				SelectionRange: d.tokenRange(s.Name),
+			}
+			if isFn {
+				body := make([]stmt.Type, 0, len(lambda.Body))
+				for _, st := range lambda.Body {
+					if st, ok := st.(stmt.Type); ok {
+						body = append(body, st)
+					}
+				}
+				sym.Kind, sym.Detail = SymbolFunction, "fn("+params(lambda.Params)+")"
+				sym.Children = d.symbols(body, false)
+			}
+			symbols = append(symbols, sym)
+		case *stmt.Import:
+			if !top {
+				continue
+			}
+			names := s.Names
+			if names == nil {
+				names = append(names, s.Alias)
+			}
+			for _, name := range names {
+				symbols = append(symbols, DocumentSymbol{
+					Name:           name.Lexeme,
+					Detail:         s.Path.Lexeme,
+					Kind:           SymbolModule,
+					Range:          d.spanRange(span),
+					SelectionRange: d.tokenRange(name),
+				})
+			}
+		}
+	}
+	return symbols
+}
+
+func (s *Server) formatting(params json.RawMessage) (interface{}, error) {
+	var p DocumentParams
+	if err := unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	out, err := format.Source([]byte(doc.text))
+	if err != nil {
+		return nil, &Error{Code: CodeRequestFailed, Message: err.Error()}
+	}
+	if string(out) == doc.text {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{
+		Range:   Range{End: doc.end()},
+		NewText: string(out),
+	}}, nil
+}
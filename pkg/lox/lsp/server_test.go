@@ -0,0 +1,326 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/wire"
+)
+
+const uri = "file:///test.lox"
+
+const src = `var greeting = "hi";
+fn greet(name) {
+  var message = greeting + name;
+  print message;
+}
+greet("you");
+`
+
+// client talks to a server running in another goroutine.
+type client struct {
+	t           *testing.T
+	in          chan []byte // messages from the server
+	out         *wire.Writer
+	done        chan error
+	id          int
+	diagnostics map[string][]Diagnostic
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &client{
+		t:           t,
+		in:          make(chan []byte, 100),
+		out:         wire.NewWriter(clientOut),
+		done:        make(chan error, 1),
+		diagnostics: make(map[string][]Diagnostic),
+	}
+	go func() {
+		r := wire.NewReader(clientIn)
+		for {
+			body, err := r.Read()
+			if err != nil {
+				close(c.in)
+				return
+			}
+			c.in <- body
+		}
+	}()
+	go func() {
+		err := NewServer().Serve(serverIn, serverOut)
+		serverOut.Close()
+		c.done <- err
+	}()
+	c.call("initialize", map[string]interface{}{}, nil)
+	c.notify("initialized", map[string]interface{}{})
+	return c
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	if err := c.out.Write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// call sends a request and decodes its result into result, recording any
+// diagnostics published in the meantime. It returns the response's error.
+func (c *client) call(method string, params, result interface{}) *Error {
+	c.t.Helper()
+	c.id++
+	if err := c.out.Write(map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		body, ok := <-c.in
+		if !ok {
+			c.t.Fatal("server hung up")
+		}
+		var msg struct {
+			ID     *int
+			Method string
+			Params json.RawMessage
+			Result json.RawMessage
+			Error  *Error
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			c.t.Fatal(err)
+		}
+		if msg.Method == "textDocument/publishDiagnostics" {
+			var p PublishDiagnosticsParams
+			json.Unmarshal(msg.Params, &p)
+			c.diagnostics[p.URI] = p.Diagnostics
+			continue
+		}
+		if msg.ID == nil || *msg.ID != c.id {
+			c.t.Fatalf("got reply %s to request %d", body, c.id)
+		}
+		if msg.Error == nil && result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return msg.Error
+	}
+}
+
+func (c *client) open(text string) {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", DidOpenParams{TextDocument: TextDocumentItem{URI: uri, Text: text}})
+}
+
+func (c *client) close() error {
+	c.t.Helper()
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	return <-c.done
+}
+
+func at(line, char int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: char},
+	}
+}
+
+func rng(line, start, end int) Range {
+	return Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: end}}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	table := map[string]struct {
+		src  string
+		want []Diagnostic
+	}{
+		"ok": {
+			src:  src,
+			want: []Diagnostic{},
+		},
+		"syntax error": {
+			src: "print 1;\nprint (1;",
+			want: []Diagnostic{{
+				Range:    rng(1, 8, 9),
+				Severity: SeverityError,
+				Source:   "ilox",
+				Message:  "Expect ')' after expression.",
+			}},
+		},
+		"undefined": {
+			src: "print clock() + nope;",
+			want: []Diagnostic{{
+				Range:    rng(0, 16, 20),
+				Severity: SeverityWarning,
+				Source:   "ilox",
+				Message:  `Undefined variable: "nope".`,
+			}},
+		},
+	}
+	for name, test := range table {
+		t.Run(name, func(t *testing.T) {
+			c.t = t
+			c.notify("textDocument/didChange", map[string]interface{}{
+				"textDocument":   TextDocumentIdentifier{URI: uri},
+				"contentChanges": []map[string]string{{"text": test.src}},
+			})
+			c.call("textDocument/hover", at(0, 0), nil) // wait for diagnostics
+			if diff := cmp.Diff(c.diagnostics[uri], test.want); diff != "" {
+				t.Errorf("incorrect diagnostics (-got,+want): %s", diff)
+			}
+		})
+	}
+	c.t = t
+	if err := c.close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	c := newClient(t)
+	c.open(src)
+
+	var defs []Location
+	c.call("textDocument/definition", at(2, 20), &defs)
+	if diff := cmp.Diff(defs, []Location{{URI: uri, Range: rng(0, 4, 12)}}); diff != "" {
+		t.Errorf("incorrect definition (-got,+want): %s", diff)
+	}
+
+	var refs []Location
+	c.call("textDocument/references", ReferenceParams{
+		TextDocumentPositionParams: at(1, 5),
+		Context: struct {
+			IncludeDeclaration bool `json:"includeDeclaration"`
+		}{IncludeDeclaration: true},
+	}, &refs)
+	want := []Location{{URI: uri, Range: rng(1, 3, 8)}, {URI: uri, Range: rng(5, 0, 5)}}
+	if diff := cmp.Diff(refs, want); diff != "" {
+		t.Errorf("incorrect references (-got,+want): %s", diff)
+	}
+
+	var hover Hover
+	c.call("textDocument/hover", at(2, 30), &hover)
+	wantHover := Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```lox\nname\n```\nparameter of fn greet, declared on line 2"},
+		Range:    rng(2, 27, 31),
+	}
+	if diff := cmp.Diff(hover, wantHover); diff != "" {
+		t.Errorf("incorrect hover (-got,+want): %s", diff)
+	}
+
+	if err := c.call("textDocument/definition", at(0, 0), &defs); err != nil || defs != nil {
+		t.Errorf("definition of a keyword got %v, %v; want nothing", defs, err)
+	}
+	if err := c.close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.open(src)
+
+	var items []CompletionItem
+	c.call("textDocument/completion", at(3, 2), &items)
+	got := make(map[string]int)
+	for _, item := range items {
+		got[item.Label] = item.Kind
+	}
+	for label, kind := range map[string]int{
+		"message":  CompletionVariable,
+		"name":     CompletionVariable,
+		"greet":    CompletionFunction,
+		"greeting": CompletionVariable,
+		"clock":    CompletionVariable,
+		"return":   CompletionKeyword,
+	} {
+		if got[label] != kind {
+			t.Errorf("got completion %q of kind %d, want %d", label, got[label], kind)
+		}
+	}
+
+	c.call("textDocument/completion", at(6, 0), &items)
+	for _, item := range items {
+		if item.Label == "message" || item.Label == "name" {
+			t.Errorf("completed %q out of its scope", item.Label)
+		}
+	}
+	if err := c.close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	c := newClient(t)
+	c.open(`import "m" as m;
+fn outer(a) {
+  fn inner() {}
+  var local = 1;
+}
+var f = x => x;`)
+
+	var symbols []DocumentSymbol
+	c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols)
+	summary := func(symbols []DocumentSymbol) []string {
+		var out []string
+		var walk func(prefix string, symbols []DocumentSymbol)
+		walk = func(prefix string, symbols []DocumentSymbol) {
+			for _, sym := range symbols {
+				out = append(out, fmt.Sprintf("%s%s %d %s", prefix, sym.Name, sym.Kind, sym.Detail))
+				walk(prefix+sym.Name+".", sym.Children)
+			}
+		}
+		walk("", symbols)
+		return out
+	}
+	want := []string{`m 2 "m"`, "outer 12 fn(a)", "outer.inner 12 fn()", "f 12 fn(x)"}
+	if diff := cmp.Diff(summary(symbols), want); diff != "" {
+		t.Errorf("incorrect symbols (-got,+want): %s", diff)
+	}
+	if diff := cmp.Diff(symbols[1].Range, Range{Start: Position{Line: 1}, End: Position{Line: 4, Character: 1}}); diff != "" {
+		t.Errorf("incorrect range (-got,+want): %s", diff)
+	}
+	if err := c.close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	params := DocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+
+	c.open("var  x=1;\nprint x ;")
+	var edits []TextEdit
+	c.call("textDocument/formatting", params, &edits)
+	want := []TextEdit{{Range: Range{End: Position{Line: 1, Character: 9}}, NewText: "var x = 1;\nprint x;\n"}}
+	if diff := cmp.Diff(edits, want); diff != "" {
+		t.Errorf("incorrect edits (-got,+want): %s", diff)
+	}
+
+	c.open("print (;")
+	if err := c.call("textDocument/formatting", params, &edits); err == nil || err.Code != CodeRequestFailed {
+		t.Errorf("formatting a syntax error got %v, want a failed request", err)
+	}
+	if err := c.close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	c := newClient(t)
+	if err := c.call("no/such/method", nil, nil); err == nil || err.Code != CodeMethodNotFound {
+		t.Errorf("unknown method got %v, want method not found", err)
+	}
+	if err := c.call("textDocument/hover", "nonsense", nil); err == nil || err.Code != CodeInvalidParams {
+		t.Errorf("bad params got %v, want invalid params", err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrNoShutdown {
+		t.Errorf("exit without shutdown got %v, want %v", err, ErrNoShutdown)
+	}
+}
@@ -9,24 +9,70 @@ import (
 	. "github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
 )
 
+const maxArgs = 255
+
 type Parser struct {
-	tokens  []Token
-	current int
-	tracker *errtrack.Tracker
+	tokens    []Token
+	current   int
+	funcDepth int // number of enclosing function bodies
+	spans     map[interface{}]Span
+	tracker   *errtrack.Tracker
+}
+
+// Span holds the first and last tokens of a node.
+type Span struct {
+	Start, End Token
 }
 
 func New(tracker *errtrack.Tracker, toks []Token) *Parser {
 	return &Parser{
 		tokens:  toks,
 		current: 0,
+		spans:   make(map[interface{}]Span),
 		tracker: tracker,
 	}
 }
 
+// Span returns the span of a statement or fn expression that p parsed.
+// Statements parsed as part of another, like the declaration in an export, do
+// not have their own span.
+func (p *Parser) Span(node interface{}) (Span, bool) {
+	span, ok := p.spans[node]
+	return span, ok
+}
+
+// Statements returns the statements that p parsed with spans of their own, in
+// no particular order.
+func (p *Parser) Statements() []stmt.Type {
+	var stmts []stmt.Type
+	for node := range p.spans {
+		if st, ok := node.(stmt.Type); ok {
+			stmts = append(stmts, st)
+		}
+	}
+	return stmts
+}
+
 func (p *Parser) AST() []stmt.Type {
 	return p.parse()
 }
 
+// Expression parses the tokens as one expression, which may end with a
+// semicolon, for tools that evaluate expressions outside of a program. It
+// returns nil if there were errors.
+func (p *Parser) Expression() (e expr.Type) {
+	defer p.tracker.CatchFatal(func() { e = nil })
+	e = p.expression()
+	p.match(SEMICOLON)
+	if !p.atEnd() {
+		p.tracker.Fatal(errtrack.LoxError{
+			Message: errors.New("Expect end of expression."),
+			Token:   p.peek(),
+		})
+	}
+	return e
+}
+
 func (p *Parser) parse() []stmt.Type {
 	var statements []stmt.Type
 	for !p.atEnd() {
@@ -35,11 +81,30 @@ func (p *Parser) parse() []stmt.Type {
 	return statements
 }
 
-func (p *Parser) declaration() stmt.Type {
+func (p *Parser) declaration() (s stmt.Type) {
+	start := p.peek()
 	defer p.tracker.CatchFatal(p.synchronize)
+	defer func() {
+		if s != nil {
+			p.spans[s] = Span{Start: start, End: p.previous()}
+		}
+	}()
 	if p.match(VAR) {
 		return p.varDeclaration()
 	}
+	if p.check(FN) && p.checkNext(IDENT) {
+		p.advance()
+		return p.function("function")
+	}
+	if p.match(IMPORT) {
+		return p.importDeclaration()
+	}
+	if p.match(FROM) {
+		return p.fromDeclaration()
+	}
+	if p.match(EXPORT) {
+		return p.exportDeclaration()
+	}
 	return p.statement()
 }
 
@@ -55,10 +120,89 @@ func (p *Parser) varDeclaration() stmt.Type {
 	return &stmt.Var{name, init}
 }
 
+func (p *Parser) function(kind string) stmt.Type {
+	name := p.consume(IDENT, "Expect "+kind+" name.")
+	p.consume(LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	params, body := p.functionBody(kind)
+	return &stmt.Function{Name: name, Params: params, Body: body}
+}
+
+// functionBody parses the parameters and body of a function after its opening
+// parenthesis.
+func (p *Parser) functionBody(kind string) ([]Token, []stmt.Type) {
+	params := p.parameters()
+	p.consume(LEFT_BRACE, "Expect '{' before "+kind+" body.")
+
+	p.funcDepth += 1
+	defer func() {
+		p.funcDepth -= 1
+	}()
+	return params, p.block()
+}
+
+func (p *Parser) parameters() []Token {
+	var params []Token
+	if !p.check(RIGHT_PAREN) {
+		for {
+			if len(params) >= maxArgs {
+				p.tracker.Report(errtrack.LoxError{
+					Message: errors.New("Can't have more than 255 parameters."),
+					Token:   p.peek(),
+				})
+			}
+			params = append(params, p.consume(IDENT, "Expect parameter name."))
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
+	return params
+}
+
+func (p *Parser) importDeclaration() stmt.Type {
+	path := p.consume(STRING, "Expect module path after 'import'.")
+	p.consume(AS, "Expect 'as' after module path.")
+	alias := p.consume(IDENT, "Expect module name after 'as'.")
+	p.consume(SEMICOLON, "Expect ';' after import.")
+	return &stmt.Import{Path: path, Alias: alias}
+}
+
+func (p *Parser) fromDeclaration() stmt.Type {
+	path := p.consume(STRING, "Expect module path after 'from'.")
+	p.consume(IMPORT, "Expect 'import' after module path.")
+
+	names := []Token{p.consume(IDENT, "Expect name to import.")}
+	for p.match(COMMA) {
+		names = append(names, p.consume(IDENT, "Expect name to import."))
+	}
+
+	p.consume(SEMICOLON, "Expect ';' after import.")
+	return &stmt.Import{Path: path, Names: names}
+}
+
+func (p *Parser) exportDeclaration() stmt.Type {
+	if p.match(VAR) {
+		return &stmt.Export{Decl: p.varDeclaration()}
+	}
+	if p.match(FN) {
+		return &stmt.Export{Decl: p.function("function")}
+	}
+
+	p.tracker.Fatal(errtrack.LoxError{
+		Message: errors.New("Expect declaration after 'export'."),
+		Token:   p.peek(),
+	})
+	return nil // unreachable
+}
+
 func (p *Parser) statement() stmt.Type {
 	if p.match(PRINT) {
 		return p.printStatement()
 	}
+	if p.match(RETURN) {
+		return p.returnStatement()
+	}
 	if p.match(LEFT_BRACE) {
 		return &stmt.Block{p.block()}
 	}
@@ -80,6 +224,24 @@ func (p *Parser) printStatement() stmt.Type {
 	return &stmt.Print{val}
 }
 
+func (p *Parser) returnStatement() stmt.Type {
+	keyword := p.previous()
+	if p.funcDepth == 0 {
+		p.tracker.Report(errtrack.LoxError{
+			Message: errors.New("Can't return from top-level code."),
+			Token:   keyword,
+		})
+	}
+
+	var val expr.Type
+	if !p.check(SEMICOLON) {
+		val = p.expression()
+	}
+
+	p.consume(SEMICOLON, "Expect ';' after return value.")
+	return &stmt.Return{Keyword: keyword, Value: val}
+}
+
 func (p *Parser) expressionStatement() stmt.Type {
 	e := p.expression()
 	p.consume(SEMICOLON, "Expect ';' after value.")
@@ -91,7 +253,7 @@ func (p *Parser) expression() expr.Type {
 }
 
 func (p *Parser) assignment() expr.Type {
-	e := p.equality()
+	e := p.ternary()
 
 	if p.match(EQUAL) {
 		equals := p.previous()
@@ -99,12 +261,61 @@ func (p *Parser) assignment() expr.Type {
 		switch left := e.(type) {
 		case *expr.Variable:
 			return &expr.Assign{left.Name, right}
+		case *expr.Index:
+			return &expr.SetIndex{
+				Object:  left.Object,
+				Bracket: left.Bracket,
+				Index:   left.Index,
+				Value:   right,
+			}
 		default:
 			p.tracker.Report(errtrack.LoxError{
 				Message: errors.New("Invalid assignment target."),
 				Token:   equals,
 			})
 		}
+	} else if p.match(PLUS_EQUAL, MINUS_EQUAL, STAR_EQUAL, SLASH_EQUAL, PERCENT_EQUAL) {
+		op := p.previous()
+		right := p.assignment()
+		if p.assignable(e, op) {
+			return &expr.Compound{
+				Target: e,
+				Op:     op,
+				Value:  right,
+			}
+		}
+	}
+
+	return e
+}
+
+// assignable reports whether e can be the target of op, reporting an error if
+// not.
+func (p *Parser) assignable(e expr.Type, op Token) bool {
+	switch e.(type) {
+	case *expr.Variable, *expr.Index:
+		return true
+	default:
+		p.tracker.Report(errtrack.LoxError{
+			Message: errors.New("Invalid assignment target."),
+			Token:   op,
+		})
+		return false
+	}
+}
+
+func (p *Parser) ternary() expr.Type {
+	e := p.equality()
+
+	if p.match(QUESTION) {
+		then := p.expression()
+		p.consume(COLON, "Expect ':' after then branch of conditional.")
+		els := p.ternary()
+		return &expr.Ternary{
+			Cond: e,
+			Then: then,
+			Else: els,
+		}
 	}
 
 	return e
@@ -127,9 +338,74 @@ func (p *Parser) equality() expr.Type {
 }
 
 func (p *Parser) comparison() expr.Type {
-	e := p.addition()
+	e := p.bitOr()
 
 	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
+		op := p.previous()
+		right := p.bitOr()
+		e = &expr.Binary{
+			Left:  e,
+			Right: right,
+			Op:    op,
+		}
+	}
+
+	return e
+}
+
+// The bitwise operators bind tighter than comparisons, unlike in C.
+func (p *Parser) bitOr() expr.Type {
+	e := p.bitXor()
+
+	for p.match(PIPE) {
+		op := p.previous()
+		right := p.bitXor()
+		e = &expr.Binary{
+			Left:  e,
+			Right: right,
+			Op:    op,
+		}
+	}
+
+	return e
+}
+
+func (p *Parser) bitXor() expr.Type {
+	e := p.bitAnd()
+
+	for p.match(CARET) {
+		op := p.previous()
+		right := p.bitAnd()
+		e = &expr.Binary{
+			Left:  e,
+			Right: right,
+			Op:    op,
+		}
+	}
+
+	return e
+}
+
+func (p *Parser) bitAnd() expr.Type {
+	e := p.shift()
+
+	for p.match(AMPERSAND) {
+		op := p.previous()
+		right := p.shift()
+		e = &expr.Binary{
+			Left:  e,
+			Right: right,
+			Op:    op,
+		}
+	}
+
+	return e
+}
+
+func (p *Parser) shift() expr.Type {
+	e := p.addition()
+
+	for p.match(LESS_LESS, GREATER_GREATER) {
 		op := p.previous()
 		right := p.addition()
 		e = &expr.Binary{
@@ -161,7 +437,7 @@ func (p *Parser) addition() expr.Type {
 func (p *Parser) multiplication() expr.Type {
 	e := p.unary()
 
-	for p.match(SLASH, STAR) {
+	for p.match(SLASH, STAR, PERCENT, TILDE_SLASH) {
 		op := p.previous()
 		right := p.unary()
 		e = &expr.Binary{
@@ -175,7 +451,7 @@ func (p *Parser) multiplication() expr.Type {
 }
 
 func (p *Parser) unary() expr.Type {
-	if p.match(BANG, MINUS) {
+	if p.match(BANG, MINUS, TILDE) {
 		op := p.previous()
 		right := p.unary()
 		return &expr.Unary{
@@ -184,7 +460,121 @@ func (p *Parser) unary() expr.Type {
 		}
 	}
 
-	return p.primary()
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		op := p.previous()
+		// The target stops short of a power, which it is the base of, but a
+		// unary target is parsed whole to report that it cannot be assigned.
+		var target expr.Type
+		switch p.peek().Typ {
+		case BANG, MINUS, TILDE, PLUS_PLUS, MINUS_MINUS:
+			target = p.unary()
+		default:
+			target = p.postfix()
+		}
+		if p.assignable(target, op) {
+			return p.exponent(&expr.Increment{
+				Target: target,
+				Op:     op,
+				Prefix: true,
+			})
+		}
+		return target
+	}
+
+	return p.power()
+}
+
+// power is right associative and binds tighter than a unary on its left. As in
+// JavaScript, an increment on its left is its base, as in ++x ** 2.
+func (p *Parser) power() expr.Type {
+	return p.exponent(p.postfix())
+}
+
+// exponent parses the rest of a power whose base has been parsed, if there is
+// one.
+func (p *Parser) exponent(base expr.Type) expr.Type {
+	if p.match(STAR_STAR) {
+		op := p.previous()
+		right := p.unary()
+		return &expr.Binary{
+			Left:  base,
+			Right: right,
+			Op:    op,
+		}
+	}
+
+	return base
+}
+
+func (p *Parser) postfix() expr.Type {
+	e := p.call()
+
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		op := p.previous()
+		if p.assignable(e, op) {
+			return &expr.Increment{
+				Target: e,
+				Op:     op,
+				Prefix: false,
+			}
+		}
+	}
+
+	return e
+}
+
+func (p *Parser) call() expr.Type {
+	e := p.primary()
+
+	for {
+		if p.match(LEFT_PAREN) {
+			e = p.finishCall(e)
+		} else if p.match(LEFT_BRACKET) {
+			bracket := p.previous()
+			index := p.expression()
+			p.consume(RIGHT_BRACKET, "Expect ']' after index.")
+			e = &expr.Index{
+				Object:  e,
+				Bracket: bracket,
+				Index:   index,
+			}
+		} else if p.match(DOT) {
+			name := p.consume(IDENT, "Expect property name after '.'.")
+			e = &expr.Get{
+				Object: e,
+				Name:   name,
+			}
+		} else {
+			break
+		}
+	}
+
+	return e
+}
+
+func (p *Parser) finishCall(callee expr.Type) expr.Type {
+	var args []expr.Type
+	if !p.check(RIGHT_PAREN) {
+		for {
+			if len(args) >= maxArgs {
+				p.tracker.Report(errtrack.LoxError{
+					Message: errors.New("Can't have more than 255 arguments."),
+					Token:   p.peek(),
+				})
+			}
+			args = append(args, p.expression())
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	paren := p.consume(RIGHT_PAREN, "Expect ')' after arguments.")
+	return &expr.Call{
+		Callee: callee,
+		Paren:  paren,
+		Args:   args,
+	}
 }
 
 func (p *Parser) primary() expr.Type {
@@ -196,12 +586,25 @@ func (p *Parser) primary() expr.Type {
 		return &expr.Literal{nil}
 	} else if p.match(NUMBER, STRING) {
 		return &expr.Literal{p.previous().Lit}
+	} else if p.match(INTERPOLATION) {
+		return p.interpolation()
+	} else if p.check(LEFT_PAREN) && p.isArrow() {
+		p.advance()
+		return p.arrow(p.parameters())
 	} else if p.match(LEFT_PAREN) {
 		e := p.expression()
 		p.consume(RIGHT_PAREN, "Expect ')' after expression.")
 		return &expr.Grouping{e}
+	} else if p.check(IDENT) && p.checkNext(ARROW) {
+		return p.arrow([]Token{p.advance()})
 	} else if p.match(IDENT) {
 		return &expr.Variable{p.previous()}
+	} else if p.match(FN) {
+		return p.lambda()
+	} else if p.match(LEFT_BRACKET) {
+		return p.list()
+	} else if p.match(LEFT_BRACE) {
+		return p.mapLiteral()
 	}
 
 	p.tracker.Fatal(errtrack.LoxError{
@@ -211,6 +614,91 @@ func (p *Parser) primary() expr.Type {
 	return nil
 }
 
+func (p *Parser) list() expr.Type {
+	bracket := p.previous()
+	var elements []expr.Type
+	for !p.check(RIGHT_BRACKET) {
+		elements = append(elements, p.expression())
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(RIGHT_BRACKET, "Expect ']' after list elements.")
+	return &expr.List{Bracket: bracket, Elements: elements}
+}
+
+// mapLiteral parses key: value pairs. A brace that begins a statement is a
+// block, so map literals may only appear where an expression is expected.
+func (p *Parser) mapLiteral() expr.Type {
+	brace := p.previous()
+	var keys, values []expr.Type
+	for !p.check(RIGHT_BRACE) {
+		keys = append(keys, p.expression())
+		p.consume(COLON, "Expect ':' after map key.")
+		values = append(values, p.expression())
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(RIGHT_BRACE, "Expect '}' after map entries.")
+	return &expr.Map{Brace: brace, Keys: keys, Values: values}
+}
+
+// interpolation parses the expressions and segments of an interpolated string
+// after its first segment.
+func (p *Parser) interpolation() expr.Type {
+	parts := []expr.Type{&expr.Literal{Value: p.previous().Lit}}
+	for {
+		parts = append(parts, p.expression())
+		if p.match(INTERPOLATION) {
+			parts = append(parts, &expr.Literal{Value: p.previous().Lit})
+			continue
+		}
+		end := p.consume(STRING, "Expect '}' after interpolated expression.")
+		parts = append(parts, &expr.Literal{Value: end.Lit})
+		return &expr.Interpolation{Parts: parts}
+	}
+}
+
+func (p *Parser) lambda() expr.Type {
+	keyword := p.previous()
+	p.consume(LEFT_PAREN, "Expect '(' after 'fn'.")
+	params, body := p.functionBody("function")
+
+	// Box the body; see the note on expr.Lambda.
+	boxed := make([]interface{}, len(body))
+	for i := range body {
+		boxed[i] = body[i]
+	}
+	lambda := &expr.Lambda{Keyword: keyword, Params: params, Body: boxed}
+	p.spans[lambda] = Span{Start: keyword, End: p.previous()}
+	return lambda
+}
+
+// arrow parses the body of an arrow function, which is a single expression
+// that is returned. The parameters have already been consumed.
+func (p *Parser) arrow(params []Token) expr.Type {
+	arrow := p.consume(ARROW, "Expect '=>' after parameters.")
+	val := p.assignment()
+	return &expr.Lambda{
+		Keyword: arrow,
+		Params:  params,
+		Body:    []interface{}{&stmt.Return{Keyword: arrow, Value: val}},
+	}
+}
+
+// isArrow reports whether the parenthesis at the current token opens the
+// parameter list of an arrow function rather than a grouping.
+func (p *Parser) isArrow() bool {
+	j := p.current + 1
+	for ; j < len(p.tokens) && p.tokens[j].Typ != RIGHT_PAREN; j++ {
+		if typ := p.tokens[j].Typ; typ != IDENT && typ != COMMA {
+			return false
+		}
+	}
+	return j+1 < len(p.tokens) && p.tokens[j+1].Typ == ARROW
+}
+
 func (p *Parser) match(types ...TokenType) bool {
 	for _, typ := range types {
 		if p.check(typ) {
@@ -229,6 +717,13 @@ func (p *Parser) check(typ TokenType) bool {
 	return p.tokens[p.current].Typ == typ
 }
 
+func (p *Parser) checkNext(typ TokenType) bool {
+	if p.atEnd() || p.current+1 >= len(p.tokens) {
+		return false
+	}
+	return p.tokens[p.current+1].Typ == typ
+}
+
 func (p *Parser) atEnd() bool {
 	return p.current >= len(p.tokens) || p.peek().Typ == EOF
 }
@@ -282,6 +777,12 @@ func (p *Parser) synchronize() {
 			return
 		case RETURN:
 			return
+		case IMPORT:
+			return
+		case FROM:
+			return
+		case EXPORT:
+			return
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package parse
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -21,12 +22,24 @@ func TestParse(t *testing.T) {
 		wanterr  bool
 	}{{
 		in:       `1`,
-		wantExpr: &expr.Literal{1.0},
+		wantExpr: &expr.Literal{int64(1)},
+	}, {
+		in:       `1.5`,
+		wantExpr: &expr.Literal{Value: 1.5},
+	}, {
+		in:       `1.0`,
+		wantExpr: &expr.Literal{Value: 1.0},
+	}, {
+		in:       `99999999999999999999`,
+		wantExpr: &expr.Literal{Value: bigInt("99999999999999999999")},
+	}, {
+		in:       `1.10d`,
+		wantExpr: &expr.Literal{Value: big.NewRat(11, 10)},
 	}, {
 		in: `1 == 2`,
 		wantExpr: &expr.Binary{
-			Left:  &expr.Literal{1.0},
-			Right: &expr.Literal{2.0},
+			Left:  &expr.Literal{int64(1)},
+			Right: &expr.Literal{int64(2)},
 			Op: Token{
 				Typ: EQUAL_EQUAL,
 			},
@@ -34,8 +47,8 @@ func TestParse(t *testing.T) {
 	}, {
 		in: `2 != 1`,
 		wantExpr: &expr.Binary{
-			Left:  &expr.Literal{2.0},
-			Right: &expr.Literal{1.0},
+			Left:  &expr.Literal{int64(2)},
+			Right: &expr.Literal{int64(1)},
 			Op: Token{
 				Typ: BANG_EQUAL,
 			},
@@ -44,8 +57,8 @@ func TestParse(t *testing.T) {
 		in: `2 != 1 == true`,
 		wantExpr: &expr.Binary{
 			Left: &expr.Binary{
-				Left:  &expr.Literal{2.0},
-				Right: &expr.Literal{1.0},
+				Left:  &expr.Literal{int64(2)},
+				Right: &expr.Literal{int64(1)},
 				Op:    Token{Typ: BANG_EQUAL},
 			},
 			Right: &expr.Literal{true},
@@ -54,24 +67,24 @@ func TestParse(t *testing.T) {
 	}, {
 		in: `1 < 2`,
 		wantExpr: &expr.Binary{
-			Left:  &expr.Literal{1.0},
-			Right: &expr.Literal{2.0},
+			Left:  &expr.Literal{int64(1)},
+			Right: &expr.Literal{int64(2)},
 			Op:    Token{Typ: LESS},
 		},
 	}, {
 		in: `1 + 2`,
 		wantExpr: &expr.Binary{
-			Left:  &expr.Literal{1.0},
-			Right: &expr.Literal{2.0},
+			Left:  &expr.Literal{int64(1)},
+			Right: &expr.Literal{int64(2)},
 			Op:    Token{Typ: PLUS},
 		},
 	}, {
 		in: `1 + 2 * 3`,
 		wantExpr: &expr.Binary{
-			Left: &expr.Literal{1.0},
+			Left: &expr.Literal{int64(1)},
 			Right: &expr.Binary{
-				Left:  &expr.Literal{2.0},
-				Right: &expr.Literal{3.0},
+				Left:  &expr.Literal{int64(2)},
+				Right: &expr.Literal{int64(3)},
 				Op:    Token{Typ: STAR},
 			},
 			Op: Token{Typ: PLUS},
@@ -80,7 +93,7 @@ func TestParse(t *testing.T) {
 		in: `-12`,
 		wantExpr: &expr.Unary{
 			Op:    Token{Typ: MINUS},
-			Right: &expr.Literal{12.0},
+			Right: &expr.Literal{int64(12)},
 		},
 	}, {
 		in: `!false`,
@@ -97,8 +110,8 @@ func TestParse(t *testing.T) {
 	}, {
 		in: `(1 + 2)`,
 		wantExpr: &expr.Grouping{&expr.Binary{
-			Left:  &expr.Literal{1.0},
-			Right: &expr.Literal{2.0},
+			Left:  &expr.Literal{int64(1)},
+			Right: &expr.Literal{int64(2)},
 			Op:    Token{Typ: PLUS},
 		}},
 	}, {
@@ -116,7 +129,7 @@ func TestParse(t *testing.T) {
 		in: `1;
 		print "hello world";`,
 		want: []stmt.Type{
-			&stmt.Expression{Expr: &expr.Literal{1.0}},
+			&stmt.Expression{Expr: &expr.Literal{int64(1)}},
 			&stmt.Print{Expr: &expr.Literal{"hello world"}},
 		},
 	}, {
@@ -125,18 +138,286 @@ func TestParse(t *testing.T) {
 	}, {
 		in: `myVar = 2;`,
 		want: []stmt.Type{&stmt.Expression{
-			&expr.Assign{Token{}, &expr.Literal{2.0}},
+			&expr.Assign{Token{}, &expr.Literal{int64(2)}},
 		}},
 	}, {
 		in: `{ 1; 2; 3; }`,
 		want: []stmt.Type{&stmt.Block{[]stmt.Type{
-			&stmt.Expression{&expr.Literal{1.0}},
-			&stmt.Expression{&expr.Literal{2.0}},
-			&stmt.Expression{&expr.Literal{3.0}},
+			&stmt.Expression{&expr.Literal{int64(1)}},
+			&stmt.Expression{&expr.Literal{int64(2)}},
+			&stmt.Expression{&expr.Literal{int64(3)}},
 		}}},
 	}, {
 		in:      `{ 1; 2; 3;`,
 		wanterr: true,
+	}, {
+		in: `import "mod.lox" as m;`,
+		want: []stmt.Type{&stmt.Import{
+			Path:  Token{Typ: STRING},
+			Alias: Token{Typ: IDENT},
+		}},
+	}, {
+		in: `from "mod.lox" import a, b;`,
+		want: []stmt.Type{&stmt.Import{
+			Path:  Token{Typ: STRING},
+			Names: []Token{{Typ: IDENT}, {Typ: IDENT}},
+		}},
+	}, {
+		in:      `import "mod.lox";`,
+		wanterr: true,
+	}, {
+		in: `export var x = 1;`,
+		want: []stmt.Type{&stmt.Export{
+			Decl: &stmt.Var{Name: Token{Typ: IDENT}, Initializer: &expr.Literal{Value: int64(1)}},
+		}},
+	}, {
+		in:      `export 1;`,
+		wanterr: true,
+	}, {
+		in: `fn f(a, b) { return a; }`,
+		want: []stmt.Type{&stmt.Function{
+			Name:   Token{Typ: IDENT},
+			Params: []Token{{Typ: IDENT}, {Typ: IDENT}},
+			Body: []stmt.Type{&stmt.Return{
+				Keyword: Token{Typ: RETURN},
+				Value:   &expr.Variable{Name: Token{Typ: IDENT}},
+			}},
+		}},
+	}, {
+		in:      `return 1;`,
+		wanterr: true,
+	}, {
+		in: `f(1, 2)`,
+		wantExpr: &expr.Call{
+			Callee: &expr.Variable{Name: Token{Typ: IDENT}},
+			Paren:  Token{Typ: RIGHT_PAREN},
+			Args:   []expr.Type{&expr.Literal{Value: int64(1)}, &expr.Literal{Value: int64(2)}},
+		},
+	}, {
+		in:      `f(1, 2`,
+		wanterr: true,
+	}, {
+		in: `fn (a) { return a; }`,
+		wantExpr: &expr.Lambda{
+			Keyword: Token{Typ: FN},
+			Params:  []Token{{Typ: IDENT}},
+			Body: []interface{}{&stmt.Return{
+				Keyword: Token{Typ: RETURN},
+				Value:   &expr.Variable{Name: Token{Typ: IDENT}},
+			}},
+		},
+	}, {
+		in: `(a, b) => a`,
+		wantExpr: &expr.Lambda{
+			Keyword: Token{Typ: ARROW},
+			Params:  []Token{{Typ: IDENT}, {Typ: IDENT}},
+			Body: []interface{}{&stmt.Return{
+				Keyword: Token{Typ: ARROW},
+				Value:   &expr.Variable{Name: Token{Typ: IDENT}},
+			}},
+		},
+	}, {
+		in: `a => 1`,
+		wantExpr: &expr.Lambda{
+			Keyword: Token{Typ: ARROW},
+			Params:  []Token{{Typ: IDENT}},
+			Body: []interface{}{&stmt.Return{
+				Keyword: Token{Typ: ARROW},
+				Value:   &expr.Literal{Value: int64(1)},
+			}},
+		},
+	}, {
+		in: `(a)`,
+		wantExpr: &expr.Grouping{
+			Expr: &expr.Variable{Name: Token{Typ: IDENT}},
+		},
+	}, {
+		in: `a ? b : c ? d : e`,
+		wantExpr: &expr.Ternary{
+			Cond: &expr.Variable{Name: Token{Typ: IDENT}},
+			Then: &expr.Variable{Name: Token{Typ: IDENT}},
+			Else: &expr.Ternary{
+				Cond: &expr.Variable{Name: Token{Typ: IDENT}},
+				Then: &expr.Variable{Name: Token{Typ: IDENT}},
+				Else: &expr.Variable{Name: Token{Typ: IDENT}},
+			},
+		},
+	}, {
+		in:      `a ? b`,
+		wanterr: true,
+	}, {
+		in: `2 ** 3 ** 4`,
+		wantExpr: &expr.Binary{
+			Left: &expr.Literal{Value: int64(2)},
+			Right: &expr.Binary{
+				Left:  &expr.Literal{Value: int64(3)},
+				Right: &expr.Literal{Value: int64(4)},
+				Op:    Token{Typ: STAR_STAR},
+			},
+			Op: Token{Typ: STAR_STAR},
+		},
+	}, {
+		in: `-2 ** 2`,
+		wantExpr: &expr.Unary{
+			Op: Token{Typ: MINUS},
+			Right: &expr.Binary{
+				Left:  &expr.Literal{Value: int64(2)},
+				Right: &expr.Literal{Value: int64(2)},
+				Op:    Token{Typ: STAR_STAR},
+			},
+		},
+	}, {
+		in: `1 % 2 ~/ 3`,
+		wantExpr: &expr.Binary{
+			Left: &expr.Binary{
+				Left:  &expr.Literal{Value: int64(1)},
+				Right: &expr.Literal{Value: int64(2)},
+				Op:    Token{Typ: PERCENT},
+			},
+			Right: &expr.Literal{Value: int64(3)},
+			Op:    Token{Typ: TILDE_SLASH},
+		},
+	}, {
+		in: `1 | 2 ^ 3 & 4 << 5 + 6`,
+		wantExpr: &expr.Binary{
+			Left: &expr.Literal{Value: int64(1)},
+			Right: &expr.Binary{
+				Left: &expr.Literal{Value: int64(2)},
+				Right: &expr.Binary{
+					Left: &expr.Literal{Value: int64(3)},
+					Right: &expr.Binary{
+						Left: &expr.Literal{Value: int64(4)},
+						Right: &expr.Binary{
+							Left:  &expr.Literal{Value: int64(5)},
+							Right: &expr.Literal{Value: int64(6)},
+							Op:    Token{Typ: PLUS},
+						},
+						Op: Token{Typ: LESS_LESS},
+					},
+					Op: Token{Typ: AMPERSAND},
+				},
+				Op: Token{Typ: CARET},
+			},
+			Op: Token{Typ: PIPE},
+		},
+	}, {
+		in: `~1 == 2 >> 3`,
+		wantExpr: &expr.Binary{
+			Left: &expr.Unary{
+				Op:    Token{Typ: TILDE},
+				Right: &expr.Literal{Value: int64(1)},
+			},
+			Right: &expr.Binary{
+				Left:  &expr.Literal{Value: int64(2)},
+				Right: &expr.Literal{Value: int64(3)},
+				Op:    Token{Typ: GREATER_GREATER},
+			},
+			Op: Token{Typ: EQUAL_EQUAL},
+		},
+	}, {
+		in: `x += 1`,
+		wantExpr: &expr.Compound{
+			Target: &expr.Variable{Name: Token{Typ: IDENT}},
+			Op:     Token{Typ: PLUS_EQUAL},
+			Value:  &expr.Literal{Value: int64(1)},
+		},
+	}, {
+		in:      `1 *= 2`,
+		wanterr: true,
+	}, {
+		in: `x++`,
+		wantExpr: &expr.Increment{
+			Target: &expr.Variable{Name: Token{Typ: IDENT}},
+			Op:     Token{Typ: PLUS_PLUS},
+		},
+	}, {
+		in: `--x`,
+		wantExpr: &expr.Increment{
+			Target: &expr.Variable{Name: Token{Typ: IDENT}},
+			Op:     Token{Typ: MINUS_MINUS},
+			Prefix: true,
+		},
+	}, {
+		in:      `++1`,
+		wanterr: true,
+	}, {
+		in: `++x ** 2`,
+		wantExpr: &expr.Binary{
+			Left: &expr.Increment{
+				Target: &expr.Variable{Name: Token{Typ: IDENT}},
+				Op:     Token{Typ: PLUS_PLUS},
+				Prefix: true,
+			},
+			Right: &expr.Literal{Value: int64(2)},
+			Op:    Token{Typ: STAR_STAR},
+		},
+	}, {
+		in:      `++-x`,
+		wanterr: true,
+	}, {
+		in: `"a ${b} c ${1}"`,
+		wantExpr: &expr.Interpolation{Parts: []expr.Type{
+			&expr.Literal{Value: "a "},
+			&expr.Variable{Name: Token{Typ: IDENT}},
+			&expr.Literal{Value: " c "},
+			&expr.Literal{Value: int64(1)},
+			&expr.Literal{Value: ""},
+		}},
+	}, {
+		in:      `"a ${b c}"`,
+		wanterr: true,
+	}, {
+		in: `[1, x[2]]`,
+		wantExpr: &expr.List{
+			Bracket: Token{Typ: LEFT_BRACKET},
+			Elements: []expr.Type{
+				&expr.Literal{Value: int64(1)},
+				&expr.Index{
+					Object:  &expr.Variable{Name: Token{Typ: IDENT}},
+					Bracket: Token{Typ: LEFT_BRACKET},
+					Index:   &expr.Literal{Value: int64(2)},
+				},
+			},
+		},
+	}, {
+		in: `x[0] = 1`,
+		wantExpr: &expr.SetIndex{
+			Object:  &expr.Variable{Name: Token{Typ: IDENT}},
+			Bracket: Token{Typ: LEFT_BRACKET},
+			Index:   &expr.Literal{Value: int64(0)},
+			Value:   &expr.Literal{Value: int64(1)},
+		},
+	}, {
+		in:      `[1, 2`,
+		wanterr: true,
+	}, {
+		in: `m = {"a": 1, b: 2}`,
+		wantExpr: &expr.Assign{
+			Name: Token{Typ: IDENT},
+			Value: &expr.Map{
+				Brace: Token{Typ: LEFT_BRACE},
+				Keys: []expr.Type{
+					&expr.Literal{Value: "a"},
+					&expr.Variable{Name: Token{Typ: IDENT}},
+				},
+				Values: []expr.Type{
+					&expr.Literal{Value: int64(1)},
+					&expr.Literal{Value: int64(2)},
+				},
+			},
+		},
+	}, {
+		in:      `m = {"a" 1}`,
+		wanterr: true,
+	}, {
+		in: `m.x.y`,
+		wantExpr: &expr.Get{
+			Object: &expr.Get{
+				Object: &expr.Variable{Name: Token{Typ: IDENT}},
+				Name:   Token{Typ: IDENT},
+			},
+			Name: Token{Typ: IDENT},
+		},
 	}}
 
 	ignoreTokenTypeFields := cmp.FilterPath(func(path cmp.Path) bool {
@@ -151,6 +432,9 @@ func TestParse(t *testing.T) {
 		}
 	}, cmp.Ignore())
 
+	compareBig := cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 })
+	compareRat := cmp.Comparer(func(a, b *big.Rat) bool { return a.Cmp(b) == 0 })
+
 	for _, row := range table {
 		t.Run(row.in, func(t *testing.T) {
 			// quick hack to box expression tests into statements
@@ -172,9 +456,71 @@ func TestParse(t *testing.T) {
 
 			if fake.Tracker.HadError() && row.wanterr == false {
 				t.Errorf("Parse %q unexpected error %q", row.in, fake.Errors())
-			} else if diff := cmp.Diff(got, row.want, ignoreTokenTypeFields); diff != "" {
+			} else if diff := cmp.Diff(got, row.want, ignoreTokenTypeFields, compareBig, compareRat); diff != "" {
 				t.Errorf("Parse %q failed (-got, +want): %s", row.in, diff)
 			}
 		})
 	}
 }
+
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+func TestSpans(t *testing.T) {
+	fake := errtrack.NewFake()
+	p := New(fake.Tracker, scan.New(fake.Tracker, "var f = fn () {\n  print 1;\n};").Tokens())
+	ast := p.AST()
+
+	outer, ok := p.Span(ast[0])
+	if !ok {
+		t.Fatalf("no span for %v", ast[0])
+	}
+	if got := [2]string{outer.Start.Lexeme, outer.End.Lexeme}; got != [2]string{"var", ";"} {
+		t.Errorf("bad span of declaration: %v", got)
+	}
+
+	lambda := ast[0].(*stmt.Var).Initializer
+	span, ok := p.Span(lambda)
+	if !ok {
+		t.Fatalf("no span for %v", lambda)
+	}
+	if got := [2]int{span.Start.Line, span.End.Line}; got != [2]int{1, 3} {
+		t.Errorf("bad lines of fn expression: %v", got)
+	}
+	if _, ok := p.Span(lambda.(*expr.Lambda).Body[0]); !ok {
+		t.Errorf("no span for statement in fn expression")
+	}
+}
+
+func TestExpression(t *testing.T) {
+	table := map[string]struct {
+		in      string
+		want    expr.Type
+		wanterr bool
+	}{
+		"plain":          {in: `x`, want: &expr.Variable{Name: Token{Typ: IDENT, Lexeme: "x", Line: 1, Char: 1}}},
+		"semicolon":      {in: `1;`, want: &expr.Literal{Value: int64(1)}},
+		"trailing":       {in: `1 2`, wanterr: true},
+		"statement":      {in: `var x = 1;`, wanterr: true},
+		"incomplete":     {in: `1 +`, wanterr: true},
+		"two semicolons": {in: `1;;`, wanterr: true},
+	}
+
+	for name, row := range table {
+		t.Run(name, func(t *testing.T) {
+			fake := errtrack.NewFake()
+			got := New(fake.Tracker, scan.New(fake.Tracker, row.in).Tokens()).Expression()
+			if row.wanterr {
+				if got != nil || !fake.Tracker.HadError() {
+					t.Errorf("got %v, want an error", got)
+				}
+				return
+			}
+			if diff := cmp.Diff(got, row.want); diff != "" {
+				t.Errorf("incorrect expression (-got,+want): %s", diff)
+			}
+		})
+	}
+}
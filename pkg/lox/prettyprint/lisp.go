@@ -2,13 +2,30 @@ package prettyprint
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
 )
 
+// Lisp prints expressions and statements as S-expressions.
 type Lisp struct{}
 
-var _ expr.Visitor = Lisp{}
+var (
+	_ expr.Visitor = Lisp{}
+	_ stmt.Visitor = Lisp{}
+)
+
+// Program prints each statement on its own line.
+func (p Lisp) Program(stmts []stmt.Type) string {
+	var b strings.Builder
+	for _, s := range stmts {
+		b.WriteString(s.Accept(p).(string))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
 
 func (p Lisp) VisitBinary(e *expr.Binary) interface{} {
 	return fmt.Sprintf("(%s %s %s)", e.Op.Lexeme, e.Left.Accept(p).(string), e.Right.Accept(p).(string))
@@ -33,3 +50,128 @@ func (p Lisp) VisitVariable(e *expr.Variable) interface{} {
 func (p Lisp) VisitAssign(e *expr.Assign) interface{} {
 	return fmt.Sprintf("(assign %s %s)", e.Name.Lexeme, e.Value.Accept(p).(string))
 }
+
+func (p Lisp) VisitGet(e *expr.Get) interface{} {
+	return fmt.Sprintf("(get %s %s)", e.Object.Accept(p).(string), e.Name.Lexeme)
+}
+
+func (p Lisp) VisitCall(e *expr.Call) interface{} {
+	parts := []string{"call", e.Callee.Accept(p).(string)}
+	for _, arg := range e.Args {
+		parts = append(parts, arg.Accept(p).(string))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (p Lisp) VisitLambda(e *expr.Lambda) interface{} {
+	parts := []string{"lambda", "(" + lexemes(e.Params) + ")"}
+	for _, s := range e.Body {
+		parts = append(parts, s.(stmt.Type).Accept(p).(string))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (p Lisp) VisitTernary(e *expr.Ternary) interface{} {
+	return fmt.Sprintf("(?: %s %s %s)", e.Cond.Accept(p).(string), e.Then.Accept(p).(string), e.Else.Accept(p).(string))
+}
+
+func (p Lisp) VisitCompound(e *expr.Compound) interface{} {
+	return fmt.Sprintf("(%s %s %s)", e.Op.Lexeme, e.Target.Accept(p).(string), e.Value.Accept(p).(string))
+}
+
+func (p Lisp) VisitIncrement(e *expr.Increment) interface{} {
+	if e.Prefix {
+		return fmt.Sprintf("(pre%s %s)", e.Op.Lexeme, e.Target.Accept(p).(string))
+	}
+	return fmt.Sprintf("(post%s %s)", e.Op.Lexeme, e.Target.Accept(p).(string))
+}
+
+func (p Lisp) VisitInterpolation(e *expr.Interpolation) interface{} {
+	parts := []string{"interp"}
+	for _, part := range e.Parts {
+		parts = append(parts, part.Accept(p).(string))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (p Lisp) VisitList(e *expr.List) interface{} {
+	parts := []string{"list"}
+	for _, el := range e.Elements {
+		parts = append(parts, el.Accept(p).(string))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (p Lisp) VisitMap(e *expr.Map) interface{} {
+	parts := []string{"map"}
+	for j := range e.Keys {
+		parts = append(parts, e.Keys[j].Accept(p).(string), e.Values[j].Accept(p).(string))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (p Lisp) VisitIndex(e *expr.Index) interface{} {
+	return fmt.Sprintf("(index %s %s)", e.Object.Accept(p).(string), e.Index.Accept(p).(string))
+}
+
+func (p Lisp) VisitSetIndex(e *expr.SetIndex) interface{} {
+	return fmt.Sprintf("(set-index %s %s %s)", e.Object.Accept(p).(string), e.Index.Accept(p).(string), e.Value.Accept(p).(string))
+}
+
+func (p Lisp) VisitBlock(s *stmt.Block) interface{} {
+	return p.list("block", s.Statements)
+}
+
+func (p Lisp) VisitExpression(s *stmt.Expression) interface{} {
+	return fmt.Sprintf("(expr %s)", s.Expr.Accept(p).(string))
+}
+
+func (p Lisp) VisitPrint(s *stmt.Print) interface{} {
+	return fmt.Sprintf("(print %s)", s.Expr.Accept(p).(string))
+}
+
+func (p Lisp) VisitVar(s *stmt.Var) interface{} {
+	if s.Initializer == nil {
+		return fmt.Sprintf("(define %s)", s.Name.Lexeme)
+	}
+	return fmt.Sprintf("(define %s %s)", s.Name.Lexeme, s.Initializer.Accept(p).(string))
+}
+
+func (p Lisp) VisitImport(s *stmt.Import) interface{} {
+	if s.Names == nil {
+		return fmt.Sprintf("(import %s %s)", s.Path.Lexeme, s.Alias.Lexeme)
+	}
+	return fmt.Sprintf("(import %s (%s))", s.Path.Lexeme, lexemes(s.Names))
+}
+
+func (p Lisp) VisitExport(s *stmt.Export) interface{} {
+	return fmt.Sprintf("(export %s)", s.Decl.Accept(p).(string))
+}
+
+func (p Lisp) VisitFunction(s *stmt.Function) interface{} {
+	return p.list(fmt.Sprintf("fn %s (%s)", s.Name.Lexeme, lexemes(s.Params)), s.Body)
+}
+
+func (p Lisp) VisitReturn(s *stmt.Return) interface{} {
+	if s.Value == nil {
+		return "(return)"
+	}
+	return fmt.Sprintf("(return %s)", s.Value.Accept(p).(string))
+}
+
+// list prints a head followed by statements.
+func (p Lisp) list(head string, stmts []stmt.Type) string {
+	parts := []string{head}
+	for _, s := range stmts {
+		parts = append(parts, s.Accept(p).(string))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func lexemes(toks []tok.Token) string {
+	parts := make([]string, len(toks))
+	for i := range toks {
+		parts[i] = toks[i].Lexeme
+	}
+	return strings.Join(parts, " ")
+}
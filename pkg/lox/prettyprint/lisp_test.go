@@ -3,7 +3,10 @@ package prettyprint
 import (
 	"fmt"
 
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
 )
 
@@ -25,3 +28,31 @@ func ExampleLisp() {
 	// Output:
 	// (* (- 123) (grp 45.67))
 }
+
+func ExampleLisp_operators() {
+	x := &expr.Variable{Name: tok.Token{Lexeme: "x"}}
+	e := expr.Ternary{
+		Cond: &expr.Increment{Target: x, Op: tok.Token{Lexeme: "++"}},
+		Then: &expr.Compound{Target: x, Op: tok.Token{Lexeme: "+="}, Value: &expr.Literal{Value: 2}},
+		Else: &expr.Literal{Value: nil},
+	}
+
+	fmt.Println(e.Accept(&Lisp{}))
+
+	// Output:
+	// (?: (post++ (var x)) (+= (var x) 2) <nil>)
+}
+
+func ExampleLisp_Program() {
+	fake := errtrack.NewFake()
+	src := `var x = 1; fn f(a) { print a; } { f(x); } export var y;`
+	stmts := parse.New(fake.Tracker, scan.New(fake.Tracker, src).Tokens()).AST()
+
+	fmt.Print(Lisp{}.Program(stmts))
+
+	// Output:
+	// (define x 1)
+	// (fn f (a) (print (var a)))
+	// (block (expr (call (var f) (var x))))
+	// (export (define y))
+}
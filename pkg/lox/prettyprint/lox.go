@@ -0,0 +1,330 @@
+package prettyprint
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Lox prints expressions and statements as Lox source. Groupings are dropped
+// and parentheses are added only where precedence needs them, so the source
+// parses back to the same program without its groupings.
+type Lox struct {
+	indent int
+}
+
+var (
+	_ expr.Visitor = &Lox{}
+	_ stmt.Visitor = &Lox{}
+)
+
+// Precedence levels, from loosest to tightest, following the parser.
+const (
+	precAssign = iota + 1
+	precTernary
+	precEquality
+	precComparison
+	precBitOr
+	precBitXor
+	precBitAnd
+	precShift
+	precAddition
+	precMultiplication
+	precUnary
+	precPower
+	precPostfix
+	precCall
+	precPrimary
+)
+
+var binaryPrec = map[tok.TokenType]int{
+	tok.EQUAL_EQUAL:     precEquality,
+	tok.BANG_EQUAL:      precEquality,
+	tok.GREATER:         precComparison,
+	tok.GREATER_EQUAL:   precComparison,
+	tok.LESS:            precComparison,
+	tok.LESS_EQUAL:      precComparison,
+	tok.PIPE:            precBitOr,
+	tok.CARET:           precBitXor,
+	tok.AMPERSAND:       precBitAnd,
+	tok.LESS_LESS:       precShift,
+	tok.GREATER_GREATER: precShift,
+	tok.PLUS:            precAddition,
+	tok.MINUS:           precAddition,
+	tok.STAR:            precMultiplication,
+	tok.SLASH:           precMultiplication,
+	tok.PERCENT:         precMultiplication,
+	tok.TILDE_SLASH:     precMultiplication,
+	tok.STAR_STAR:       precPower,
+}
+
+// precedence returns how tightly an expression binds.
+func precedence(e expr.Type) int {
+	switch e := e.(type) {
+	case *expr.Grouping:
+		return precedence(e.Expr)
+	case *expr.Assign, *expr.SetIndex, *expr.Compound:
+		return precAssign
+	case *expr.Ternary:
+		return precTernary
+	case *expr.Binary:
+		return binaryPrec[e.Op.Typ]
+	case *expr.Unary:
+		return precUnary
+	case *expr.Increment:
+		// Even a prefix increment can be the base of a power.
+		return precPostfix
+	case *expr.Call, *expr.Get, *expr.Index:
+		return precCall
+	case *expr.Lambda:
+		// An arrow function's body takes everything to its right.
+		if e.Keyword.Typ == tok.ARROW {
+			return precAssign
+		}
+	}
+	return precPrimary
+}
+
+// Program prints each statement on its own line.
+func (p *Lox) Program(stmts []stmt.Type) string {
+	var b strings.Builder
+	for _, s := range stmts {
+		b.WriteString(strings.Repeat("  ", p.indent))
+		b.WriteString(s.Accept(p).(string))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// operand prints e, in parentheses if it binds looser than min.
+func (p *Lox) operand(e expr.Type, min int) string {
+	s := e.Accept(p).(string)
+	if precedence(e) < min {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+func (p *Lox) exprs(es []expr.Type) string {
+	parts := make([]string, len(es))
+	for i := range es {
+		parts[i] = p.operand(es[i], precAssign)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// block prints statements in braces, indented one level further.
+func (p *Lox) block(stmts []stmt.Type) string {
+	if len(stmts) == 0 {
+		return "{}"
+	}
+	p.indent++
+	body := p.Program(stmts)
+	p.indent--
+	return "{\n" + body + strings.Repeat("  ", p.indent) + "}"
+}
+
+func (p *Lox) VisitBinary(e *expr.Binary) interface{} {
+	prec := binaryPrec[e.Op.Typ]
+	left, right := prec, prec+1 // left associative
+	if e.Op.Typ == tok.STAR_STAR {
+		left, right = precPostfix, precUnary
+	}
+	return fmt.Sprintf("%s %s %s", p.operand(e.Left, left), e.Op.Lexeme, p.operand(e.Right, right))
+}
+
+func (p *Lox) VisitGrouping(e *expr.Grouping) interface{} {
+	return e.Expr.Accept(p)
+}
+
+func (p *Lox) VisitLiteral(e *expr.Literal) interface{} {
+	return Literal(e.Value)
+}
+
+func (p *Lox) VisitUnary(e *expr.Unary) interface{} {
+	right := p.operand(e.Right, precUnary)
+	if e.Op.Typ == tok.MINUS && strings.HasPrefix(right, "-") {
+		return "- " + right // not a decrement
+	}
+	return e.Op.Lexeme + right
+}
+
+func (p *Lox) VisitVariable(e *expr.Variable) interface{} {
+	return e.Name.Lexeme
+}
+
+func (p *Lox) VisitAssign(e *expr.Assign) interface{} {
+	return e.Name.Lexeme + " = " + p.operand(e.Value, precAssign)
+}
+
+func (p *Lox) VisitGet(e *expr.Get) interface{} {
+	return p.operand(e.Object, precCall) + "." + e.Name.Lexeme
+}
+
+func (p *Lox) VisitCall(e *expr.Call) interface{} {
+	return p.operand(e.Callee, precCall) + "(" + p.exprs(e.Args) + ")"
+}
+
+func (p *Lox) VisitLambda(e *expr.Lambda) interface{} {
+	params := make([]string, len(e.Params))
+	for i := range e.Params {
+		params[i] = e.Params[i].Lexeme
+	}
+	if e.Keyword.Typ == tok.ARROW {
+		return "(" + strings.Join(params, ", ") + ") => " + p.operand(e.Body[0].(*stmt.Return).Value, precAssign)
+	}
+
+	body := make([]stmt.Type, len(e.Body))
+	for i := range e.Body {
+		body[i] = e.Body[i].(stmt.Type)
+	}
+	return "fn (" + strings.Join(params, ", ") + ") " + p.block(body)
+}
+
+func (p *Lox) VisitTernary(e *expr.Ternary) interface{} {
+	return fmt.Sprintf("%s ? %s : %s", p.operand(e.Cond, precEquality), p.operand(e.Then, precAssign), p.operand(e.Else, precTernary))
+}
+
+func (p *Lox) VisitCompound(e *expr.Compound) interface{} {
+	return fmt.Sprintf("%s %s %s", p.operand(e.Target, precCall), e.Op.Lexeme, p.operand(e.Value, precAssign))
+}
+
+func (p *Lox) VisitIncrement(e *expr.Increment) interface{} {
+	if e.Prefix {
+		return e.Op.Lexeme + p.operand(e.Target, precPostfix)
+	}
+	return p.operand(e.Target, precCall) + e.Op.Lexeme
+}
+
+func (p *Lox) VisitInterpolation(e *expr.Interpolation) interface{} {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, part := range e.Parts {
+		if i%2 == 0 {
+			b.WriteString(part.(*expr.Literal).Value.(string))
+		} else {
+			b.WriteString("${" + p.operand(part, precAssign) + "}")
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (p *Lox) VisitList(e *expr.List) interface{} {
+	return "[" + p.exprs(e.Elements) + "]"
+}
+
+func (p *Lox) VisitIndex(e *expr.Index) interface{} {
+	return p.operand(e.Object, precCall) + "[" + p.operand(e.Index, precAssign) + "]"
+}
+
+func (p *Lox) VisitSetIndex(e *expr.SetIndex) interface{} {
+	return fmt.Sprintf("%s[%s] = %s", p.operand(e.Object, precCall), p.operand(e.Index, precAssign), p.operand(e.Value, precAssign))
+}
+
+func (p *Lox) VisitMap(e *expr.Map) interface{} {
+	parts := make([]string, len(e.Keys))
+	for i := range e.Keys {
+		parts[i] = p.operand(e.Keys[i], precAssign) + ": " + p.operand(e.Values[i], precAssign)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func (p *Lox) VisitBlock(s *stmt.Block) interface{} {
+	return p.block(s.Statements)
+}
+
+func (p *Lox) VisitExpression(s *stmt.Expression) interface{} {
+	e := p.operand(s.Expr, precAssign)
+	if strings.HasPrefix(e, "{") {
+		e = "(" + e + ")" // not a block
+	}
+	return e + ";"
+}
+
+func (p *Lox) VisitPrint(s *stmt.Print) interface{} {
+	return "print " + p.operand(s.Expr, precAssign) + ";"
+}
+
+func (p *Lox) VisitVar(s *stmt.Var) interface{} {
+	if s.Initializer == nil {
+		return "var " + s.Name.Lexeme + ";"
+	}
+	return "var " + s.Name.Lexeme + " = " + p.operand(s.Initializer, precAssign) + ";"
+}
+
+func (p *Lox) VisitImport(s *stmt.Import) interface{} {
+	if s.Names == nil {
+		return "import " + s.Path.Lexeme + " as " + s.Alias.Lexeme + ";"
+	}
+	names := make([]string, len(s.Names))
+	for i := range s.Names {
+		names[i] = s.Names[i].Lexeme
+	}
+	return "from " + s.Path.Lexeme + " import " + strings.Join(names, ", ") + ";"
+}
+
+func (p *Lox) VisitExport(s *stmt.Export) interface{} {
+	return "export " + s.Decl.Accept(p).(string)
+}
+
+func (p *Lox) VisitFunction(s *stmt.Function) interface{} {
+	params := make([]string, len(s.Params))
+	for i := range s.Params {
+		params[i] = s.Params[i].Lexeme
+	}
+	return "fn " + s.Name.Lexeme + "(" + strings.Join(params, ", ") + ") " + p.block(s.Body)
+}
+
+func (p *Lox) VisitReturn(s *stmt.Return) interface{} {
+	if s.Value == nil {
+		return "return;"
+	}
+	return "return " + p.operand(s.Value, precAssign) + ";"
+}
+
+// Literal prints a value as it would be written in Lox source.
+func Literal(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case *big.Int:
+		return v.String()
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case *big.Rat:
+		return decimal(v) + "d"
+	case string:
+		return `"` + v + `"`
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// decimal prints an exact decimal with as few digits as possible. Decimals
+// from source always have a denominator that divides a power of ten.
+func decimal(r *big.Rat) string {
+	pow := big.NewInt(1)
+	ten := big.NewInt(10)
+	var rem big.Int
+	for digits := 0; digits < 10*len(r.Denom().String()); digits++ {
+		if rem.Mod(pow, r.Denom()).Sign() == 0 {
+			return r.FloatString(digits)
+		}
+		pow.Mul(pow, ten)
+	}
+	return r.FloatString(10 * len(r.Denom().String()))
+}
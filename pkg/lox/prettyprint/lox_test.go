@@ -0,0 +1,81 @@
+package prettyprint
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+func ExampleLox() {
+	plus := tok.Token{Typ: tok.PLUS, Lexeme: "+"}
+	star := tok.Token{Typ: tok.STAR, Lexeme: "*"}
+	e := &expr.Binary{
+		Op:    star,
+		Left:  &expr.Binary{Op: plus, Left: &expr.Literal{Value: int64(1)}, Right: &expr.Literal{Value: int64(2)}},
+		Right: &expr.Literal{Value: 1.5},
+	}
+
+	fmt.Println(e.Accept(&Lox{}))
+
+	// Output:
+	// (1 + 2) * 1.5
+}
+
+func TestLox(t *testing.T) {
+	table := map[string]struct {
+		in, want string
+	}{
+		"redundant parens":   {in: `print ((1)) + (2 * 3);`, want: "print 1 + 2 * 3;\n"},
+		"needed parens":      {in: `print (1 + 2) * 3;`, want: "print (1 + 2) * 3;\n"},
+		"left associative":   {in: `print (1 - 2) - (3 - 4);`, want: "print 1 - 2 - (3 - 4);\n"},
+		"power":              {in: `print (2 ** 3) ** (2 ** 1);`, want: "print (2 ** 3) ** 2 ** 1;\n"},
+		"unary power":        {in: `print (-2) ** 2; print -(2 ** 2);`, want: "print (-2) ** 2;\nprint -2 ** 2;\n"},
+		"negate negative":    {in: `print -(-x);`, want: "print - -x;\n"},
+		"ternary":            {in: `print (a ? b : c) ? (d ? e : f) : (g ? h : i);`, want: "print (a ? b : c) ? d ? e : f : g ? h : i;\n"},
+		"assignment":         {in: `a = (b = c); print (a = 1) + 1;`, want: "a = b = c;\nprint (a = 1) + 1;\n"},
+		"arrow operand":      {in: `print (x => x)(1); print 1 + (x => x);`, want: "print ((x) => x)(1);\nprint 1 + ((x) => x);\n"},
+		"call chain":         {in: `print (f(1)).x[(2)];`, want: "print f(1).x[2];\n"},
+		"map statement":      {in: `({}).x;`, want: "({}.x);\n"},
+		"interpolation":      {in: `print "a${(1)}b";`, want: "print \"a${1}b\";\n"},
+		"decimals":           {in: `print 1.50 + 0.250d + 3.0;`, want: "print 1.5 + 0.25d + 3.0;\n"},
+		"declarations":       {in: `var x; fn f(a, b) {} export fn g() { return; }`, want: "var x;\nfn f(a, b) {}\nexport fn g() {\n  return;\n}\n"},
+		"imports":            {in: `import "a" as a; from "b" import c, d;`, want: "import \"a\" as a;\nfrom \"b\" import c, d;\n"},
+		"nested blocks":      {in: `{ { print 1; } }`, want: "{\n  {\n    print 1;\n  }\n}\n"},
+		"fn expression":      {in: `var f = fn (a) { print a; };`, want: "var f = fn (a) {\n  print a;\n};\n"},
+		"compound increment": {in: `x += y++; ++x[0];`, want: "x += y++;\n++x[0];\n"},
+		"increment power":    {in: `print (++x) ** 2; print -(--x) ** 2;`, want: "print ++x ** 2;\nprint - --x ** 2;\n"},
+	}
+
+	for name, tc := range table {
+		t.Run(name, func(t *testing.T) {
+			got := (&Lox{}).Program(parseString(t, tc.in))
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect output (-got,+want): %s", diff)
+			}
+
+			// The printed source must mean the same thing, so it prints the same.
+			again := (&Lox{}).Program(parseString(t, got))
+			if diff := cmp.Diff(again, got); diff != "" {
+				t.Errorf("printed source parses differently (-again,+once): %s", diff)
+			}
+		})
+	}
+}
+
+func parseString(t *testing.T, in string) []stmt.Type {
+	t.Helper()
+	fake := errtrack.NewFake()
+	stmts := parse.New(fake.Tracker, scan.New(fake.Tracker, in).Tokens()).AST()
+	if fake.Tracker.HadError() {
+		t.Fatalf("could not parse %q: %s", in, fake.Errors())
+	}
+	return stmts
+}
@@ -0,0 +1,370 @@
+// Package resolve finds the declaration each variable in a program refers to,
+// without running it, for tools like the language server.
+//
+// Blocks and function bodies are scoped as the interpreter scopes them: a name
+// used in a block refers to the closest declaration before it in that block or
+// an enclosing one. Names at the top level are bound late, so a function may
+// use a global declared after it. Names that are not declared anywhere refer
+// to builtins or are unresolved.
+package resolve
+
+import (
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/expr"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/tok"
+)
+
+// Kind is the way a name was declared.
+type Kind int
+
+const (
+	Builtin Kind = iota
+	Global
+	Local
+	Param
+	Function
+	Import
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Builtin:
+		return "builtin"
+	case Global:
+		return "global variable"
+	case Local:
+		return "local variable"
+	case Param:
+		return "parameter"
+	case Function:
+		return "function"
+	case Import:
+		return "import"
+	}
+	return "unknown"
+}
+
+// Symbol is a declared name and its uses.
+type Symbol struct {
+	Name  string
+	Kind  Kind
+	Decl  tok.Token   // the name where it is declared; zero for builtins
+	Node  interface{} // the declaring statement or lambda; nil for builtins
+	Scope *Scope
+	Refs  []tok.Token // uses after the declaration, in source order
+}
+
+// Scope is a block, function body or the top level of a program.
+type Scope struct {
+	Parent *Scope
+	// Owner is the function whose body this is, a *stmt.Function or
+	// *expr.Lambda, or nil for blocks and the top level.
+	Owner      interface{}
+	Start, End tok.Token // the extent of the scope; zero at the top level
+	Symbols    []*Symbol // declared in this scope, in source order
+	Children   []*Scope
+}
+
+// Spans finds where statements begin and end. A *parse.Parser is Spans for the
+// program it parsed.
+type Spans interface {
+	Span(node interface{}) (parse.Span, bool)
+}
+
+// Info is what Resolve learns about a program.
+type Info struct {
+	Universe *Scope // holds builtins; the parent of Top
+	Top      *Scope
+	// Unresolved lists the uses of names that are not declared anywhere.
+	Unresolved []tok.Token
+
+	symbols []*Symbol         // every declared symbol, builtins last
+	uses    map[pos]*Symbol   // by the position of each declaration and use
+	tokens  map[pos]tok.Token // the token at each such position
+}
+
+// pos is a token's position, which identifies names in a program.
+type pos struct {
+	line, char int
+}
+
+func posOf(t tok.Token) pos {
+	return pos{line: t.Line, char: t.Char}
+}
+
+// Resolve resolves every name in stmts. Statements that failed to parse may be
+// nil. Builtins are the names defined before the program runs.
+func Resolve(stmts []stmt.Type, spans Spans, builtins []string) *Info {
+	info := &Info{
+		Universe: &Scope{},
+		uses:     make(map[pos]*Symbol),
+		tokens:   make(map[pos]tok.Token),
+	}
+	for _, name := range builtins {
+		info.Universe.Symbols = append(info.Universe.Symbols, &Symbol{Name: name, Kind: Builtin, Scope: info.Universe})
+	}
+	info.Top = &Scope{Parent: info.Universe}
+	info.Universe.Children = []*Scope{info.Top}
+
+	r := &resolver{info: info, spans: spans, scope: info.Top}
+	r.stmts(stmts)
+
+	// Globals are bound late, so uses that reached the top level are resolved
+	// once every global is known.
+	for _, use := range r.late {
+		if sym := latest(info.Top, use); sym != nil {
+			r.bind(sym, use)
+		} else if sym := latest(info.Universe, use); sym != nil {
+			r.bind(sym, use)
+		} else {
+			info.Unresolved = append(info.Unresolved, use)
+		}
+	}
+	info.symbols = append(info.symbols, info.Universe.Symbols...)
+	return info
+}
+
+// Symbols returns every symbol declared in the program, in source order.
+// Builtins are not included.
+func (info *Info) Symbols() []*Symbol {
+	return info.symbols[:len(info.symbols)-len(info.Universe.Symbols)]
+}
+
+// At finds the name at the given line and column, counted as the scanner
+// counts them. It returns the symbol it refers to and the name's token.
+func (info *Info) At(line, char int) (*Symbol, tok.Token, bool) {
+	for p, t := range info.tokens {
+		if p.line == line && p.char <= char && char < p.char+len(t.Lexeme) {
+			return info.uses[p], t, true
+		}
+	}
+	return nil, tok.Token{}, false
+}
+
+// Visible returns the symbols that may be used at the given line and column,
+// from the innermost scope outwards. Names that are shadowed are left out.
+func (info *Info) Visible(line, char int) []*Symbol {
+	at := tok.Token{Line: line, Char: char}
+	scope := info.Top
+	for descended := true; descended; {
+		descended = false
+		for _, child := range scope.Children {
+			if !before(at, child.Start) && !before(child.End, at) {
+				scope, descended = child, true
+				break
+			}
+		}
+	}
+
+	var visible []*Symbol
+	seen := make(map[string]bool)
+	for ; scope != nil; scope = scope.Parent {
+		for j := len(scope.Symbols) - 1; j >= 0; j-- {
+			sym := scope.Symbols[j]
+			declared := scope == info.Top || scope == info.Universe || before(sym.Decl, at)
+			if declared && !seen[sym.Name] {
+				seen[sym.Name] = true
+				visible = append(visible, sym)
+			}
+		}
+	}
+	return visible
+}
+
+// before reports whether a is before b in the source.
+func before(a, b tok.Token) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Char < b.Char
+}
+
+// latest finds the declaration of use's name in scope that is closest before
+// use, or else the first after it.
+func latest(scope *Scope, use tok.Token) *Symbol {
+	var found *Symbol
+	for _, sym := range scope.Symbols {
+		if sym.Name != use.Lexeme {
+			continue
+		}
+		if found == nil || before(sym.Decl, use) {
+			found = sym
+		}
+	}
+	return found
+}
+
+type resolver struct {
+	info  *Info
+	spans Spans
+	scope *Scope
+	end   tok.Token   // the end of the innermost statement with a span
+	late  []tok.Token // uses not declared in any local scope
+}
+
+func (r *resolver) declare(name tok.Token, kind Kind, node interface{}) {
+	if name.Lexeme == "" {
+		return // the parser gave up
+	}
+	if kind == Local && r.scope == r.info.Top {
+		kind = Global
+	}
+	sym := &Symbol{Name: name.Lexeme, Kind: kind, Decl: name, Node: node, Scope: r.scope}
+	r.scope.Symbols = append(r.scope.Symbols, sym)
+	r.info.symbols = append(r.info.symbols, sym)
+	r.info.uses[posOf(name)] = sym
+	r.info.tokens[posOf(name)] = name
+}
+
+func (r *resolver) use(name tok.Token) {
+	for scope := r.scope; scope != r.info.Top; scope = scope.Parent {
+		for j := len(scope.Symbols) - 1; j >= 0; j-- {
+			if scope.Symbols[j].Name == name.Lexeme {
+				r.bind(scope.Symbols[j], name)
+				return
+			}
+		}
+	}
+	r.late = append(r.late, name)
+}
+
+func (r *resolver) bind(sym *Symbol, use tok.Token) {
+	sym.Refs = append(sym.Refs, use)
+	r.info.uses[posOf(use)] = sym
+	r.info.tokens[posOf(use)] = use
+}
+
+// enter starts a new scope covering start to end.
+func (r *resolver) enter(owner interface{}, start, end tok.Token) func() {
+	scope := &Scope{Parent: r.scope, Owner: owner, Start: start, End: end}
+	r.scope.Children = append(r.scope.Children, scope)
+	r.scope = scope
+	return func() {
+		r.scope = scope.Parent
+	}
+}
+
+func (r *resolver) stmts(stmts []stmt.Type) {
+	for _, s := range stmts {
+		if s != nil {
+			r.stmt(s)
+		}
+	}
+}
+
+func (r *resolver) stmt(s stmt.Type) {
+	if span, ok := r.spans.Span(s); ok {
+		outer := r.end
+		r.end = span.End
+		defer func() {
+			r.end = outer
+		}()
+	}
+
+	switch s := s.(type) {
+	case *stmt.Block:
+		span, _ := r.spans.Span(s)
+		defer r.enter(nil, span.Start, span.End)()
+		r.stmts(s.Statements)
+	case *stmt.Expression:
+		r.expr(s.Expr)
+	case *stmt.Print:
+		r.expr(s.Expr)
+	case *stmt.Var:
+		// The initializer is evaluated before the name is defined.
+		r.expr(s.Initializer)
+		r.declare(s.Name, Local, s)
+	case *stmt.Import:
+		if s.Names == nil {
+			r.declare(s.Alias, Import, s)
+		}
+		for _, name := range s.Names {
+			r.declare(name, Import, s)
+		}
+	case *stmt.Export:
+		if s.Decl != nil {
+			r.stmt(s.Decl)
+		}
+	case *stmt.Function:
+		// Declared first so that it may call itself.
+		r.declare(s.Name, Function, s)
+		r.function(s, s.Params, s.Body)
+	case *stmt.Return:
+		r.expr(s.Value)
+	}
+}
+
+// function resolves the parameters and body of a function declared by owner.
+func (r *resolver) function(owner interface{}, params []tok.Token, body []stmt.Type) {
+	start, end := tok.Token{}, r.end
+	if span, ok := r.spans.Span(owner); ok {
+		start, end = span.Start, span.End
+	} else if len(params) > 0 {
+		start = params[0]
+	} else if lambda, ok := owner.(*expr.Lambda); ok {
+		start = lambda.Keyword
+	}
+	defer r.enter(owner, start, end)()
+	for _, param := range params {
+		r.declare(param, Param, owner)
+	}
+	r.stmts(body)
+}
+
+func (r *resolver) exprs(es []expr.Type) {
+	for _, e := range es {
+		r.expr(e)
+	}
+}
+
+func (r *resolver) expr(e expr.Type) {
+	switch e := e.(type) {
+	case nil:
+	case *expr.Binary:
+		r.expr(e.Left)
+		r.expr(e.Right)
+	case *expr.Grouping:
+		r.expr(e.Expr)
+	case *expr.Unary:
+		r.expr(e.Right)
+	case *expr.Variable:
+		r.use(e.Name)
+	case *expr.Assign:
+		r.expr(e.Value)
+		r.use(e.Name)
+	case *expr.Get:
+		r.expr(e.Object)
+	case *expr.Call:
+		r.expr(e.Callee)
+		r.exprs(e.Args)
+	case *expr.Lambda:
+		body := make([]stmt.Type, 0, len(e.Body))
+		for _, s := range e.Body {
+			if s, ok := s.(stmt.Type); ok {
+				body = append(body, s)
+			}
+		}
+		r.function(e, e.Params, body)
+	case *expr.Ternary:
+		r.expr(e.Cond)
+		r.expr(e.Then)
+		r.expr(e.Else)
+	case *expr.Compound:
+		r.expr(e.Target)
+		r.expr(e.Value)
+	case *expr.Increment:
+		r.expr(e.Target)
+	case *expr.Interpolation:
+		r.exprs(e.Parts)
+	case *expr.List:
+		r.exprs(e.Elements)
+	case *expr.Index:
+		r.expr(e.Object)
+		r.expr(e.Index)
+	case *expr.SetIndex:
+		r.expr(e.Object)
+		r.expr(e.Index)
+		r.expr(e.Value)
+	case *expr.Map:
+		r.exprs(e.Keys)
+		r.exprs(e.Values)
+	}
+}
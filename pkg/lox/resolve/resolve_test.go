@@ -0,0 +1,153 @@
+package resolve
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+)
+
+func resolve(t *testing.T, src string) *Info {
+	t.Helper()
+	tracker := errtrack.NewWithOutput(ioutil.Discard)
+	parser := parse.New(tracker, scan.New(tracker, src).Tokens())
+	stmts := parser.AST()
+	if tracker.HadError() {
+		t.Fatalf("could not parse %q: %v", src, tracker.Errors())
+	}
+	return Resolve(stmts, parser, []string{"clock"})
+}
+
+func TestResolve(t *testing.T) {
+	// Uses are written name@column and map to the column of their declaration,
+	// or to "builtin" or "unresolved". Sources are on one line, where columns
+	// start at 1.
+	table := map[string]struct {
+		src  string
+		want map[string]string
+	}{
+		"global": {
+			src:  `var a = 1; print a;`,
+			want: map[string]string{"a@18": "5"},
+		},
+		"shadowed": {
+			src:  `var a = 1; { var a = a; print a; }`,
+			want: map[string]string{"a@22": "5", "a@31": "18"},
+		},
+		"late global": {
+			src:  `fn f() { return g(); } fn g() { return 1; }`,
+			want: map[string]string{"g@17": "27"},
+		},
+		"local before declaration": {
+			src:  `var a; { print a; var a; }`,
+			want: map[string]string{"a@16": "5"},
+		},
+		"params": {
+			src:  `fn f(a, b) { return a + b + f; }`,
+			want: map[string]string{"a@21": "6", "b@25": "9", "f@29": "4"},
+		},
+		"lambda": {
+			src:  `var add = (x, y) => x + y;`,
+			want: map[string]string{"x@21": "12", "y@25": "15"},
+		},
+		"closure": {
+			src:  `fn f(n) { return fn () { return n; }; }`,
+			want: map[string]string{"n@33": "6"},
+		},
+		"assign": {
+			src:  `var a; a = 2;`,
+			want: map[string]string{"a@8": "5"},
+		},
+		"import": {
+			src:  `import "m" as m; from "n" import x, y; print m.x + x;`,
+			want: map[string]string{"m@46": "15", "x@52": "34"},
+		},
+		"builtin and unresolved": {
+			src:  `print clock() + nope;`,
+			want: map[string]string{"clock@7": "builtin", "nope@17": "unresolved"},
+		},
+	}
+
+	for name, test := range table {
+		t.Run(name, func(t *testing.T) {
+			info := resolve(t, test.src)
+			got := make(map[string]string)
+			for _, sym := range append(info.Symbols(), info.Universe.Symbols...) {
+				for _, ref := range sym.Refs {
+					decl := fmt.Sprint(sym.Decl.Char)
+					if sym.Kind == Builtin {
+						decl = "builtin"
+					}
+					got[fmt.Sprintf("%s@%d", ref.Lexeme, ref.Char)] = decl
+				}
+			}
+			for _, use := range info.Unresolved {
+				got[fmt.Sprintf("%s@%d", use.Lexeme, use.Char)] = "unresolved"
+			}
+			if diff := cmp.Diff(got, test.want); diff != "" {
+				t.Errorf("incorrect resolution (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestKinds(t *testing.T) {
+	info := resolve(t, `var g; fn f(p) { var l; } import "m" as m;`)
+	got := make(map[string]Kind)
+	for _, sym := range info.Symbols() {
+		got[sym.Name] = sym.Kind
+	}
+	want := map[string]Kind{"g": Global, "f": Function, "p": Param, "l": Local, "m": Import}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect kinds (-got,+want): %s", diff)
+	}
+}
+
+func TestVisible(t *testing.T) {
+	src := `var a;
+fn f(b) {
+  var c;
+
+  var d;
+}
+var e;`
+	info := resolve(t, src)
+
+	table := map[string]struct {
+		line, char int
+		want       []string
+	}{
+		"top":           {line: 1, char: 1, want: []string{"e", "f", "a", "clock"}},
+		"in function":   {line: 4, char: 3, want: []string{"c", "b", "e", "f", "a", "clock"}},
+		"after a local": {line: 5, char: 9, want: []string{"d", "c", "b", "e", "f", "a", "clock"}},
+	}
+	for name, test := range table {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, sym := range info.Visible(test.line, test.char) {
+				got = append(got, sym.Name)
+			}
+			if diff := cmp.Diff(got, test.want); diff != "" {
+				t.Errorf("incorrect symbols (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestAt(t *testing.T) {
+	info := resolve(t, `var abc = 1; print abc;`)
+	for _, char := range []int{5, 7, 20, 22} {
+		sym, name, ok := info.At(1, char)
+		if !ok || sym.Name != "abc" || name.Lexeme != "abc" {
+			t.Errorf("At(1, %d) = %v, %v, %t; want abc", char, sym, name, ok)
+		}
+	}
+	if _, _, ok := info.At(1, 1); ok {
+		t.Errorf("At(1, 1) found a name in a keyword")
+	}
+}
@@ -1,34 +1,102 @@
 package lox
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/chzyer/readline"
 
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/astjson"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/dap"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/debug"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/errtrack"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/parse"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/prettyprint"
 	"github.com/spencer-p/craftinginterpreters/pkg/lox/scan"
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/stmt"
 )
 
-// RunFile interprets the code in the given file.
-func RunFile(path string) error {
+var (
+	// ErrStatic means a script could not be scanned or parsed.
+	ErrStatic = errors.New("script has syntax errors")
+	// ErrRuntime means a script stopped with a runtime error.
+	ErrRuntime = errors.New("script failed at runtime")
+)
+
+// RunFile interprets the code in the given file with the given options. Errors
+// in the script are reported to opts.Stderr, and RunFile returns ErrStatic or
+// ErrRuntime.
+func RunFile(path string, opts interpret.Options) error {
 	bytes, err := fetchFile(path)
 	if err != nil {
 		return err
 	}
 
 	// free utf-8 support! thanks, go
-	run(string(bytes))
+	return RunSource(string(bytes), path, opts)
+}
+
+// RunSource interprets src like RunFile, as if it were in the file at path.
+// The path need not exist; imports are found relative to it.
+func RunSource(src, path string, opts interpret.Options) error {
+	return run(src, moduleName(path), opts)
+}
+
+// DebugFile runs the script at path under a command line debugger, which
+// reads commands from in and writes to out. The script pauses before its
+// first statement. Errors are reported like RunFile's, and stopping the script
+// from the debugger is not an error.
+func DebugFile(path string, opts interpret.Options, in io.Reader, out io.Writer) error {
+	src, err := fetchFile(path)
+	if err != nil {
+		return err
+	}
+
+	tracker := errtrack.New()
+	if opts.Stderr != nil {
+		tracker = errtrack.NewWithOutput(opts.Stderr)
+	}
+	parser := parse.New(tracker, scan.New(tracker, string(src)).Tokens())
+	ast := parser.AST()
+	if tracker.HadError() {
+		return ErrStatic
+	}
+
+	interpreter := interpret.New(tracker, opts)
+	interpreter.SetLoader(osLoader(moduleName(path)), moduleName(path))
+	console := debug.NewConsole(string(src), in, out)
+	err = debug.New(interpreter, parser, console.Handle, true).Run(context.Background(), ast)
+	switch {
+	case errors.Is(err, debug.ErrStopped):
+		return nil
+	case err != nil:
+		return ErrRuntime
+	}
 	return nil
 }
 
-// RunPrompt interprets code interactively.
-func RunPrompt() error {
+// ServeDAP serves a Debug Adapter Protocol client on r and w, debugging scripts
+// and their imports from the host's file system like RunFile.
+func ServeDAP(r io.Reader, w io.Writer) error {
+	return dap.NewServer(func(program string) (interpret.ModuleLoader, string) {
+		name := moduleName(program)
+		return osLoader(name), name
+	}).Serve(r, w)
+}
+
+// RunPrompt interprets code interactively, with options like RunFile.
+func RunPrompt(opts interpret.Options) error {
 	rl, err := readline.New("> ")
 	if err != nil {
 		return fmt.Errorf("could not run interactive: %v", err)
@@ -44,11 +112,109 @@ func RunPrompt() error {
 				return fmt.Errorf("failed to read user input: %v", err)
 			}
 		}
-		run(line)
+		run(line, moduleName("<stdin>"), opts)
 	}
 	return nil
 }
 
+// Check scans and parses src without running it, reporting any errors to
+// standard error. It returns ErrStatic if there were errors.
+func Check(src string) error {
+	_, err := parseSource(errtrack.New(), src)
+	return err
+}
+
+// parseSource scans and parses src, returning ErrStatic if the tracker saw
+// errors.
+func parseSource(tracker *errtrack.Tracker, src string) ([]stmt.Type, error) {
+	toks := scan.New(tracker, src).Tokens()
+	if tracker.HadError() {
+		return nil, ErrStatic
+	}
+	ast := parse.New(tracker, toks).AST()
+	if tracker.HadError() {
+		return nil, ErrStatic
+	}
+	return ast, nil
+}
+
+// DumpTokens scans src and writes its tokens to w, as a "table" with one
+// token per line or as "json" like the tokens in astjson. Scan errors are
+// reported to standard error after the tokens that could be scanned.
+func DumpTokens(src, form string, w io.Writer) error {
+	tracker := errtrack.New()
+	toks := scan.New(tracker, src).Tokens()
+
+	switch form {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(tw, "POSITION\tTYPE\tLEXEME\tLITERAL\n")
+		for _, t := range toks {
+			lit := ""
+			if t.Lit != nil {
+				lit = prettyprint.Literal(t.Lit)
+			}
+			fmt.Fprintf(tw, "%d:%d\t%s\t%s\t%s\n", t.Line, t.Char, t.Typ, t.Lexeme, lit)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	case "json":
+		data, err := astjson.MarshalTokens(toks)
+		if err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		json.Indent(&out, data, "", "  ")
+		out.WriteByte('\n')
+		if _, err := out.WriteTo(w); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown token form %q", form)
+	}
+
+	if tracker.HadError() {
+		return ErrStatic
+	}
+	return nil
+}
+
+// astPrinters print a parsed program in each form DumpAST supports.
+var astPrinters = map[string]func([]stmt.Type) (string, error){
+	"sexpr": func(stmts []stmt.Type) (string, error) {
+		return prettyprint.Lisp{}.Program(stmts), nil
+	},
+	"lox": func(stmts []stmt.Type) (string, error) {
+		return (&prettyprint.Lox{}).Program(stmts), nil
+	},
+	"json": func(stmts []stmt.Type) (string, error) {
+		data, err := astjson.MarshalIndent(stmts, "", "  ")
+		return string(data) + "\n", err
+	},
+}
+
+// DumpAST parses src and writes its syntax tree to w instead of running it. The
+// form is "sexpr", "lox" or "json". Parse errors are reported to standard error
+// and DumpAST returns ErrStatic.
+func DumpAST(src, form string, w io.Writer) error {
+	printer, ok := astPrinters[form]
+	if !ok {
+		return fmt.Errorf("unknown AST form %q", form)
+	}
+	ast, err := parseSource(errtrack.New(), src)
+	if err != nil {
+		return err
+	}
+
+	out, err := printer(ast)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
 // fetchFile turns a path into the bytes of the corresponding file.
 // The file is loaded into memory and the file resource is cleaned up.
 func fetchFile(path string) ([]byte, error) {
@@ -66,18 +232,43 @@ func fetchFile(path string) ([]byte, error) {
 	return bytes, nil
 }
 
-func run(in string) {
-	tracker := errtrack.New()
+// osLoader loads modules from the host file system for the script with the
+// given module name. Directories listed in the LOXPATH environment variable are
+// searched after the importing file's directory. Only modules in the script's
+// directory, the LOXPATH directories and their subdirectories can be imported.
+func osLoader(name string) *interpret.FSLoader {
+	loader := &interpret.FSLoader{FS: os.DirFS("/"), Roots: []string{path.Dir(name)}}
+	for _, dir := range filepath.SplitList(os.Getenv("LOXPATH")) {
+		loader.SearchPath = append(loader.SearchPath, moduleName(dir))
+	}
+	loader.Roots = append(loader.Roots, loader.SearchPath...)
+	return loader
+}
 
-	toks := scan.New(tracker, in).Tokens()
-	if tracker.HadError() {
-		return
+// moduleName converts a host path into a name in osLoader's file system.
+func moduleName(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
 	}
+	return strings.TrimPrefix(filepath.ToSlash(abs), "/")
+}
 
-	ast := parse.New(tracker, toks).AST()
-	if tracker.HadError() {
-		return
+func run(in string, name string, opts interpret.Options) error {
+	tracker := errtrack.New()
+	if opts.Stderr != nil {
+		tracker = errtrack.NewWithOutput(opts.Stderr)
+	}
+
+	ast, err := parseSource(tracker, in)
+	if err != nil {
+		return err
 	}
 
-	interpret.New(tracker).Interpret(ast)
+	interpreter := interpret.New(tracker, opts)
+	interpreter.SetLoader(osLoader(name), name)
+	if err := interpreter.InterpretContext(context.Background(), ast); err != nil {
+		return ErrRuntime
+	}
+	return nil
 }
@@ -0,0 +1,135 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/spencer-p/craftinginterpreters/pkg/lox/interpret"
+)
+
+func TestDumpTokens(t *testing.T) {
+	table := map[string]struct {
+		src  string
+		form string
+		want string
+	}{
+		"table": {
+			src:  `var x = "hi";`,
+			form: "table",
+			want: `POSITION  TYPE       LEXEME  LITERAL
+1:1       VAR        var     
+1:5       IDENT      x       
+1:7       EQUAL      =       
+1:9       STRING     "hi"    "hi"
+1:13      SEMICOLON  ;       
+1:14      EOF                
+`,
+		},
+		"json": {
+			src:  `1`,
+			form: "json",
+			want: `[
+  {
+    "type": "NUMBER",
+    "lexeme": "1",
+    "line": 1,
+    "char": 1,
+    "literal": {
+      "type": "int",
+      "value": "1"
+    }
+  },
+  {
+    "type": "EOF",
+    "lexeme": "",
+    "line": 1,
+    "char": 2
+  }
+]
+`,
+		},
+	}
+
+	for name, test := range table {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := DumpTokens(test.src, test.form, &out); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(out.String(), test.want); diff != "" {
+				t.Errorf("incorrect tokens (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	table := map[string]struct {
+		src  string
+		want error
+	}{
+		"ok":      {src: `print 1 + 2;`},
+		"scan":    {src: `print @;`, want: ErrStatic},
+		"parse":   {src: `print (1;`, want: ErrStatic},
+		"runtime": {src: `print -"x";`, want: ErrRuntime},
+	}
+
+	for name, test := range table {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := RunSource(test.src, "<test>", interpret.Options{Stdout: &stdout, Stderr: &stderr})
+			if !errors.Is(err, test.want) {
+				t.Errorf("RunSource got %v, want %v", err, test.want)
+			}
+			if (test.want == nil) != (stderr.Len() == 0) {
+				t.Errorf("RunSource got error %v but reported %q", err, stderr.String())
+			}
+
+			wantStatic := test.want
+			if wantStatic == ErrRuntime {
+				wantStatic = nil
+			}
+			if err := Check(test.src); !errors.Is(err, wantStatic) {
+				t.Errorf("Check got %v, want %v", err, wantStatic)
+			}
+		})
+	}
+}
+
+func TestImportOutsideScriptDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"outside.lox":   `export var leaked = "from outside";`,
+		"sb/inside.lox": `export var ok = "from inside";`,
+		"sb/main.lox":   `import "inside.lox" as i; print i.ok;`,
+		"sb/escape.lox": `import "../outside.lox" as o; print o.leaked;`,
+	}
+	for name, src := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Setenv("LOXPATH", os.Getenv("LOXPATH"))
+	os.Setenv("LOXPATH", "")
+
+	var stdout, stderr bytes.Buffer
+	opts := interpret.Options{Stdout: &stdout, Stderr: &stderr}
+	if err := RunFile(filepath.Join(dir, "sb/main.lox"), opts); err != nil || stdout.String() != "from inside\n" {
+		t.Errorf("import inside the script's directory got %v and output %q", err, stdout.String())
+	}
+	stdout.Reset()
+	if err := RunFile(filepath.Join(dir, "sb/escape.lox"), opts); !errors.Is(err, ErrRuntime) || stdout.Len() > 0 {
+		t.Errorf("import outside the script's directory got %v and output %q", err, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), interpret.ErrorOutsideRoots.Error()) {
+		t.Errorf("got errors %q, want %q", stderr.String(), interpret.ErrorOutsideRoots)
+	}
+}
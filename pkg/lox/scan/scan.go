@@ -3,7 +3,9 @@ package scan
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
@@ -19,23 +21,31 @@ var (
 		')': RIGHT_PAREN,
 		'{': LEFT_BRACE,
 		'}': RIGHT_BRACE,
+		'[': LEFT_BRACKET,
+		']': RIGHT_BRACKET,
 		',': COMMA,
 		'.': DOT,
-		'-': MINUS,
-		'+': PLUS,
 		';': SEMICOLON,
-		'*': STAR,
+		'?': QUESTION,
+		':': COLON,
+		'&': AMPERSAND,
+		'|': PIPE,
+		'^': CARET,
 	}
 
 	RESERVED = map[string]TokenType{
 		"and":    AND,
+		"as":     AS,
 		"class":  CLASS,
 		"else":   ELSE,
+		"export": EXPORT,
 		"false":  FALSE,
 		"for":    FOR,
 		"fn":     FN, // I prefer fn over Lox's fun.
 		"fun":    FN, // However, we support both.
+		"from":   FROM,
 		"if":     IF,
+		"import": IMPORT,
 		"nil":    NIL,
 		"or":     OR,
 		"print":  PRINT,
@@ -51,6 +61,7 @@ var (
 type Scanner struct {
 	src        string
 	tokens     []Token
+	comments   []Token
 	start, cur int
 	line       int // line we're on
 	linei      int // index of line
@@ -61,6 +72,10 @@ type Scanner struct {
 	}
 	lookaheadi int
 
+	// For each string interpolation we are inside of, the number of unclosed
+	// braces within it.
+	interpolations []int
+
 	tracker *errtrack.Tracker
 }
 
@@ -82,11 +97,27 @@ func (s *Scanner) Tokens() []Token {
 		s.scanToken()
 	}
 
+	if len(s.interpolations) > 0 {
+		s.tracker.Report(errtrack.LoxError{
+			Message: errors.New("Unterminated string interpolation."),
+			Token: Token{
+				Line: s.line,
+				Char: s.charLineIndex(),
+			},
+		})
+	}
+
 	s.tokens = append(s.tokens, Token{EOF, "", nil, s.line, s.charLineIndex() + 1})
 
 	return s.tokens
 }
 
+// Comments returns the comments skipped by Tokens, in order. Each is a COMMENT
+// token whose lexeme is the text of the comment, including the slashes.
+func (s *Scanner) Comments() []Token {
+	return s.comments
+}
+
 func (s *Scanner) atEnd() bool {
 	return s.cur >= len(s.src)
 }
@@ -94,6 +125,20 @@ func (s *Scanner) atEnd() bool {
 func (s *Scanner) scanToken() {
 	r := s.advance()
 
+	// braces may close an interpolation
+	if n := len(s.interpolations); n > 0 {
+		switch {
+		case r == '{':
+			s.interpolations[n-1] += 1
+		case r == '}' && s.interpolations[n-1] == 0:
+			s.interpolations = s.interpolations[:n-1]
+			s.eatString()
+			return
+		case r == '}':
+			s.interpolations[n-1] -= 1
+		}
+	}
+
 	// simple lookups first
 	if tok, ok := ONEWIDTHS[r]; ok {
 		s.addToken1(tok)
@@ -105,19 +150,40 @@ func (s *Scanner) scanToken() {
 	case '!':
 		s.addToken1(s.match('=', BANG_EQUAL, BANG))
 	case '=':
-		s.addToken1(s.match('=', EQUAL_EQUAL, EQUAL))
+		if s.peek() == '>' {
+			s.advance()
+			s.addToken1(ARROW)
+		} else {
+			s.addToken1(s.match('=', EQUAL_EQUAL, EQUAL))
+		}
 	case '<':
-		s.addToken1(s.match('=', LESS_EQUAL, LESS))
+		s.addToken1(s.match2('=', LESS_EQUAL, '<', LESS_LESS, LESS))
 	case '>':
-		s.addToken1(s.match('=', GREATER_EQUAL, GREATER))
+		s.addToken1(s.match2('=', GREATER_EQUAL, '>', GREATER_GREATER, GREATER))
+	case '+':
+		s.addToken1(s.match2('=', PLUS_EQUAL, '+', PLUS_PLUS, PLUS))
+	case '-':
+		s.addToken1(s.match2('=', MINUS_EQUAL, '-', MINUS_MINUS, MINUS))
+	case '*':
+		s.addToken1(s.match2('=', STAR_EQUAL, '*', STAR_STAR, STAR))
+	case '%':
+		s.addToken1(s.match('=', PERCENT_EQUAL, PERCENT))
+	case '~':
+		s.addToken1(s.match('/', TILDE_SLASH, TILDE))
 	case '/':
 		if s.peek() == '/' {
-			// this is a comment -- consume it
+			// this is a comment -- consume it, but keep it for formatting
 			for s.peek() != '\n' && !s.atEnd() {
 				s.advance()
 			}
+			s.comments = append(s.comments, Token{
+				Typ:    COMMENT,
+				Lexeme: strings.TrimRight(s.src[s.start:s.cur], " \t\r"),
+				Line:   s.line,
+				Char:   s.charLineIndex(),
+			})
 		} else {
-			s.addToken1(SLASH)
+			s.addToken1(s.match('=', SLASH_EQUAL, SLASH))
 		}
 	case '"':
 		s.eatString()
@@ -133,19 +199,23 @@ func (s *Scanner) scanToken() {
 		} else if isAlphaNum(r) {
 			s.eatIdent()
 		} else {
-			s.tracker.Report(errtrack.LoxError{
-				Message: fmt.Errorf("Unexpected rune %q", r),
-				Token: Token{
-					Lexeme: string(r),
-					Line:   s.line,
-					Char:   s.charLineIndex(),
-				},
-			})
+			s.unexpected(r)
 		}
 	}
 
 }
 
+func (s *Scanner) unexpected(r rune) {
+	s.tracker.Report(errtrack.LoxError{
+		Message: fmt.Errorf("Unexpected rune %q", r),
+		Token: Token{
+			Lexeme: string(r),
+			Line:   s.line,
+			Char:   s.charLineIndex(),
+		},
+	})
+}
+
 func (s *Scanner) peek() rune {
 	if s.atEnd() {
 		return 0
@@ -180,6 +250,9 @@ func (s *Scanner) peekNext() rune {
 
 	// we now know the peeked character's width. read the peek next
 	offset := s.lookahead[0].width
+	if s.cur+offset >= len(s.src) {
+		return 0 // nothing after the peek
+	}
 	next, width := utf8.DecodeRuneInString(s.src[s.cur+offset:])
 	s.lookahead[1].char = next
 	s.lookahead[1].width = width
@@ -211,8 +284,26 @@ func (s *Scanner) match(expect rune, match, nomatch TokenType) TokenType {
 	return match
 }
 
+// match2 is match with two possible second characters.
+func (s *Scanner) match2(expect1 rune, match1 TokenType, expect2 rune, match2 TokenType, nomatch TokenType) TokenType {
+	if typ := s.match(expect1, match1, nomatch); typ != nomatch {
+		return typ
+	}
+	return s.match(expect2, match2, nomatch)
+}
+
+// eatString scans the rest of a string that opened at s.start, or resumes one
+// after an interpolated expression. If the string contains an interpolation,
+// only the segment before it is scanned.
 func (s *Scanner) eatString() {
 	for s.peek() != '"' && !s.atEnd() {
+		if s.peek() == '$' && s.peekNext() == '{' {
+			s.advance()
+			s.advance()
+			s.interpolations = append(s.interpolations, 0)
+			s.addToken(INTERPOLATION, s.src[s.start+1:s.cur-2])
+			return
+		}
 		if s.peek() == '\n' {
 			s.line += 1
 		}
@@ -244,7 +335,9 @@ func (s *Scanner) eatNumber() {
 		s.advance()
 	}
 
+	isFloat := false
 	if s.peek() == '.' && unicode.IsDigit(s.peekNext()) {
+		isFloat = true
 		s.advance() // the dot character
 
 		for unicode.IsDigit(s.peek()) {
@@ -252,8 +345,29 @@ func (s *Scanner) eatNumber() {
 		}
 	}
 
+	// Numbers with a d suffix are exact decimals, those with a decimal point are
+	// floats, and the rest are integers. Integers too large for an int64 are
+	// big integers.
 	substr := s.src[s.start:s.cur]
-	val, err := strconv.ParseFloat(substr, 64)
+	var val interface{}
+	var err error
+	if s.peek() == 'd' && !isAlphaNum(s.peekNext()) {
+		s.advance()
+		if rat, ok := new(big.Rat).SetString(substr); ok {
+			val = rat
+		} else {
+			err = strconv.ErrSyntax
+		}
+		substr = s.src[s.start:s.cur]
+	} else if isFloat {
+		val, err = strconv.ParseFloat(substr, 64)
+	} else if n, ok := new(big.Int).SetString(substr, 10); !ok {
+		err = strconv.ErrSyntax
+	} else if n.IsInt64() {
+		val = n.Int64()
+	} else {
+		val = n
+	}
 	if err != nil {
 		s.tracker.Report(errtrack.LoxError{
 			Message: fmt.Errorf("Number does not parse: %v.", err),
@@ -17,7 +17,7 @@ func TestScanner(t *testing.T) {
 		in:   `(( )){}`,
 		want: []TokenType{LEFT_PAREN, LEFT_PAREN, RIGHT_PAREN, RIGHT_PAREN, LEFT_BRACE, RIGHT_BRACE, EOF},
 	}, {
-		in:   `!*+-/=<>`,
+		in:   `!*+-/ =<>`,
 		want: []TokenType{BANG, STAR, PLUS, MINUS, SLASH, EQUAL, LESS, GREATER, EOF},
 	}, {
 		in:   `// this is a comment +=<=(){}`,
@@ -41,6 +41,30 @@ func TestScanner(t *testing.T) {
 	}, {
 		in:   `0.123`,
 		want: []TokenType{NUMBER, EOF},
+	}, {
+		in:   `1.10d 3d`,
+		want: []TokenType{NUMBER, NUMBER, EOF},
+	}, {
+		in:   `3do`,
+		want: []TokenType{NUMBER, IDENT, EOF},
+	}, {
+		in:   `(a) => a == b`,
+		want: []TokenType{LEFT_PAREN, IDENT, RIGHT_PAREN, ARROW, IDENT, EQUAL_EQUAL, IDENT, EOF},
+	}, {
+		in:   `+= ++ + -= -- - *= ** * /= / %= % ~/ ? :`,
+		want: []TokenType{PLUS_EQUAL, PLUS_PLUS, PLUS, MINUS_EQUAL, MINUS_MINUS, MINUS, STAR_EQUAL, STAR_STAR, STAR, SLASH_EQUAL, SLASH, PERCENT_EQUAL, PERCENT, TILDE_SLASH, QUESTION, COLON, EOF},
+	}, {
+		in:   `& | ^ ~ << <= < >> >= >`,
+		want: []TokenType{AMPERSAND, PIPE, CARET, TILDE, LESS_LESS, LESS_EQUAL, LESS, GREATER_GREATER, GREATER_EQUAL, GREATER, EOF},
+	}, {
+		in:   `"a ${b} c ${{}} d"`,
+		want: []TokenType{INTERPOLATION, IDENT, INTERPOLATION, LEFT_BRACE, RIGHT_BRACE, STRING, EOF},
+	}, {
+		in:   `"a ${"b ${c}"}"`,
+		want: []TokenType{INTERPOLATION, INTERPOLATION, IDENT, STRING, STRING, EOF},
+	}, {
+		in:   `import from as export`,
+		want: []TokenType{IMPORT, FROM, AS, EXPORT, EOF},
 	}}
 
 	for _, test := range table {
@@ -55,3 +79,21 @@ func TestScanner(t *testing.T) {
 		}
 	}
 }
+
+func TestComments(t *testing.T) {
+	s := New(errtrack.New(), "// one  \nprint 1; // two\n\t//three")
+	tokens := s.Tokens()
+	if diff := cmp.Diff(len(tokens), 4); diff != "" {
+		t.Errorf("comments were scanned as tokens (-got, +want): %s", diff)
+	}
+
+	// Comments are placed like any other token.
+	want := []Token{
+		{Typ: COMMENT, Lexeme: "// one", Line: 1, Char: 1},
+		{Typ: COMMENT, Lexeme: "// two", Line: 2, Char: tokens[2].Char + 2},
+		{Typ: COMMENT, Lexeme: "//three", Line: 3, Char: 3},
+	}
+	if diff := cmp.Diff(s.Comments(), want); diff != "" {
+		t.Errorf("bad comments (-got, +want): %s", diff)
+	}
+}
@@ -9,3 +9,7 @@ package stmt
 /// Expression: Expr expr.Type
 /// Print: Expr expr.Type
 /// Var: Name tok.Token, Initializer expr.Type
+/// Import: Path tok.Token, Alias tok.Token, Names []tok.Token
+/// Export: Decl Type
+/// Function: Name tok.Token, Params []tok.Token, Body []Type
+/// Return: Keyword tok.Token, Value expr.Type
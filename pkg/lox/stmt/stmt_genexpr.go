@@ -14,6 +14,10 @@ type Visitor interface {
 	VisitExpression(*Expression) interface{}
 	VisitPrint(*Print) interface{}
 	VisitVar(*Var) interface{}
+	VisitImport(*Import) interface{}
+	VisitExport(*Export) interface{}
+	VisitFunction(*Function) interface{}
+	VisitReturn(*Return) interface{}
 }
 
 type Block struct {
@@ -49,3 +53,51 @@ func (e *Var) Accept(v Visitor) interface{} {
 	return v.VisitVar(e)
 }
 
+type Import struct {
+	Path tok.Token
+	Alias tok.Token
+	Names []tok.Token
+}
+
+func (e *Import) Accept(v Visitor) interface{} {
+	return v.VisitImport(e)
+}
+
+type Export struct {
+	Decl Type
+}
+
+func (e *Export) Accept(v Visitor) interface{} {
+	return v.VisitExport(e)
+}
+
+type Function struct {
+	Name tok.Token
+	Params []tok.Token
+	Body []Type
+}
+
+func (e *Function) Accept(v Visitor) interface{} {
+	return v.VisitFunction(e)
+}
+
+type Return struct {
+	Keyword tok.Token
+	Value expr.Type
+}
+
+func (e *Return) Accept(v Visitor) interface{} {
+	return v.VisitReturn(e)
+}
+
+// Kinds makes an empty node of each type, by name.
+var Kinds = map[string]func() Type{
+	"Block": func() Type { return &Block{} },
+	"Expression": func() Type { return &Expression{} },
+	"Print": func() Type { return &Print{} },
+	"Var": func() Type { return &Var{} },
+	"Import": func() Type { return &Import{} },
+	"Export": func() Type { return &Export{} },
+	"Function": func() Type { return &Function{} },
+	"Return": func() Type { return &Return{} },
+}
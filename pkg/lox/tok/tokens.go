@@ -16,6 +16,8 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
 	MINUS
@@ -23,6 +25,13 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT
+	QUESTION
+	COLON
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
 
 	// One or two character tokens.
 	BANG
@@ -33,20 +42,37 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	ARROW
+	PLUS_EQUAL
+	PLUS_PLUS
+	MINUS_EQUAL
+	MINUS_MINUS
+	STAR_EQUAL
+	STAR_STAR
+	SLASH_EQUAL
+	PERCENT_EQUAL
+	TILDE_SLASH
+	LESS_LESS
+	GREATER_GREATER
 
 	// Literals.
 	IDENT
 	STRING
+	INTERPOLATION // string segment followed by an interpolated expression
 	NUMBER
 
 	// Keywords.
 	AND
+	AS
 	CLASS
 	ELSE
+	EXPORT
 	FALSE
 	FN
 	FOR
+	FROM
 	IF
+	IMPORT
 	NIL
 	OR
 	PRINT
@@ -57,6 +83,9 @@ const (
 	VAR
 	WHILE
 
+	// Comments are kept apart from the other tokens.
+	COMMENT
+
 	// Denote end of file
 	EOF
 )
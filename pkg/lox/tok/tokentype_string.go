@@ -13,46 +13,73 @@ func _() {
 	_ = x[RIGHT_PAREN-2]
 	_ = x[LEFT_BRACE-3]
 	_ = x[RIGHT_BRACE-4]
-	_ = x[COMMA-5]
-	_ = x[DOT-6]
-	_ = x[MINUS-7]
-	_ = x[PLUS-8]
-	_ = x[SEMICOLON-9]
-	_ = x[SLASH-10]
-	_ = x[STAR-11]
-	_ = x[BANG-12]
-	_ = x[BANG_EQUAL-13]
-	_ = x[EQUAL-14]
-	_ = x[EQUAL_EQUAL-15]
-	_ = x[GREATER-16]
-	_ = x[GREATER_EQUAL-17]
-	_ = x[LESS-18]
-	_ = x[LESS_EQUAL-19]
-	_ = x[IDENT-20]
-	_ = x[STRING-21]
-	_ = x[NUMBER-22]
-	_ = x[AND-23]
-	_ = x[CLASS-24]
-	_ = x[ELSE-25]
-	_ = x[FALSE-26]
-	_ = x[FN-27]
-	_ = x[FOR-28]
-	_ = x[IF-29]
-	_ = x[NIL-30]
-	_ = x[OR-31]
-	_ = x[PRINT-32]
-	_ = x[RETURN-33]
-	_ = x[SUPER-34]
-	_ = x[THIS-35]
-	_ = x[TRUE-36]
-	_ = x[VAR-37]
-	_ = x[WHILE-38]
-	_ = x[EOF-39]
+	_ = x[LEFT_BRACKET-5]
+	_ = x[RIGHT_BRACKET-6]
+	_ = x[COMMA-7]
+	_ = x[DOT-8]
+	_ = x[MINUS-9]
+	_ = x[PLUS-10]
+	_ = x[SEMICOLON-11]
+	_ = x[SLASH-12]
+	_ = x[STAR-13]
+	_ = x[PERCENT-14]
+	_ = x[QUESTION-15]
+	_ = x[COLON-16]
+	_ = x[AMPERSAND-17]
+	_ = x[PIPE-18]
+	_ = x[CARET-19]
+	_ = x[TILDE-20]
+	_ = x[BANG-21]
+	_ = x[BANG_EQUAL-22]
+	_ = x[EQUAL-23]
+	_ = x[EQUAL_EQUAL-24]
+	_ = x[GREATER-25]
+	_ = x[GREATER_EQUAL-26]
+	_ = x[LESS-27]
+	_ = x[LESS_EQUAL-28]
+	_ = x[ARROW-29]
+	_ = x[PLUS_EQUAL-30]
+	_ = x[PLUS_PLUS-31]
+	_ = x[MINUS_EQUAL-32]
+	_ = x[MINUS_MINUS-33]
+	_ = x[STAR_EQUAL-34]
+	_ = x[STAR_STAR-35]
+	_ = x[SLASH_EQUAL-36]
+	_ = x[PERCENT_EQUAL-37]
+	_ = x[TILDE_SLASH-38]
+	_ = x[LESS_LESS-39]
+	_ = x[GREATER_GREATER-40]
+	_ = x[IDENT-41]
+	_ = x[STRING-42]
+	_ = x[INTERPOLATION-43]
+	_ = x[NUMBER-44]
+	_ = x[AND-45]
+	_ = x[AS-46]
+	_ = x[CLASS-47]
+	_ = x[ELSE-48]
+	_ = x[EXPORT-49]
+	_ = x[FALSE-50]
+	_ = x[FN-51]
+	_ = x[FOR-52]
+	_ = x[FROM-53]
+	_ = x[IF-54]
+	_ = x[IMPORT-55]
+	_ = x[NIL-56]
+	_ = x[OR-57]
+	_ = x[PRINT-58]
+	_ = x[RETURN-59]
+	_ = x[SUPER-60]
+	_ = x[THIS-61]
+	_ = x[TRUE-62]
+	_ = x[VAR-63]
+	_ = x[WHILE-64]
+	_ = x[COMMENT-65]
+	_ = x[EOF-66]
 }
 
-const _TokenType_name = "INVALIDLEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACECOMMADOTMINUSPLUSSEMICOLONSLASHSTARBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALIDENTSTRINGNUMBERANDCLASSELSEFALSEFNFORIFNILORPRINTRETURNSUPERTHISTRUEVARWHILEEOF"
+const _TokenType_name = "INVALIDLEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACELEFT_BRACKETRIGHT_BRACKETCOMMADOTMINUSPLUSSEMICOLONSLASHSTARPERCENTQUESTIONCOLONAMPERSANDPIPECARETTILDEBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALARROWPLUS_EQUALPLUS_PLUSMINUS_EQUALMINUS_MINUSSTAR_EQUALSTAR_STARSLASH_EQUALPERCENT_EQUALTILDE_SLASHLESS_LESSGREATER_GREATERIDENTSTRINGINTERPOLATIONNUMBERANDASCLASSELSEEXPORTFALSEFNFORFROMIFIMPORTNILORPRINTRETURNSUPERTHISTRUEVARWHILECOMMENTEOF"
 
-var _TokenType_index = [...]uint8{0, 7, 17, 28, 38, 49, 54, 57, 62, 66, 75, 80, 84, 88, 98, 103, 114, 121, 134, 138, 148, 153, 159, 165, 168, 173, 177, 182, 184, 187, 189, 192, 194, 199, 205, 210, 214, 218, 221, 226, 229}
+var _TokenType_index = [...]uint16{0, 7, 17, 28, 38, 49, 61, 74, 79, 82, 87, 91, 100, 105, 109, 116, 124, 129, 138, 142, 147, 152, 156, 166, 171, 182, 189, 202, 206, 216, 221, 231, 240, 251, 262, 272, 281, 292, 305, 316, 325, 340, 345, 351, 364, 370, 373, 375, 380, 384, 390, 395, 397, 400, 404, 406, 412, 415, 417, 422, 428, 433, 437, 441, 444, 449, 456, 459}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
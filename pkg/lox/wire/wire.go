@@ -0,0 +1,101 @@
+// Package wire reads and writes JSON messages framed by a Content-Length
+// header, the way the Language Server and Debug Adapter Protocols send them
+// over standard streams.
+package wire
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNoLength = errors.New("wire: message has no Content-Length")
+	ErrTooLong  = errors.New("wire: message is too long")
+)
+
+// MaxLength is the longest message body Read accepts, so that a bad header
+// cannot make it allocate without bound.
+const MaxLength = 64 << 20
+
+// Reader reads framed messages.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the body of the next message. It returns io.EOF if the stream
+// ends between messages.
+func (r *Reader) Read() ([]byte, error) {
+	length := -1
+	for first := true; ; first = false {
+		line, err := r.r.ReadString('\n')
+		if err == io.EOF && first && line == "" {
+			return nil, io.EOF
+		} else if err == io.EOF {
+			return nil, fmt.Errorf("wire: reading header: %w", io.ErrUnexpectedEOF)
+		} else if err != nil {
+			return nil, fmt.Errorf("wire: reading header: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		colon := strings.IndexByte(line, ':')
+		if colon < 0 {
+			return nil, fmt.Errorf("wire: bad header %q", line)
+		}
+		if strings.EqualFold(line[:colon], "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[colon+1:]))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("wire: bad header %q", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, ErrNoLength
+	}
+	if length > MaxLength {
+		return nil, ErrTooLong
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.r, body); err != nil {
+		return nil, fmt.Errorf("wire: reading body: %w", err)
+	}
+	return body, nil
+}
+
+// Writer writes framed messages. It is safe to use from several goroutines.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write encodes v as JSON and writes it as one message.
+func (w *Writer) Write(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := fmt.Fprintf(w.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.w.Write(body)
+	return err
+}
@@ -0,0 +1,61 @@
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, v := range []interface{}{map[string]int{"a": 1}, "héllo", nil} {
+		if err := w.Write(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewReader(&buf)
+	var got []string
+	for {
+		body, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(body))
+	}
+	want := []string{`{"a":1}`, `"héllo"`, `null`}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect messages (-got,+want): %s", diff)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	table := map[string]string{
+		"no length":    "Content-Type: json\r\n\r\n{}",
+		"bad length":   "Content-Length: x\r\n\r\n{}",
+		"bad header":   "nonsense\r\n\r\n{}",
+		"short body":   "Content-Length: 10\r\n\r\n{}",
+		"short header": "Content-Length: 2\r\n",
+	}
+	for name, in := range table {
+		t.Run(name, func(t *testing.T) {
+			if body, err := NewReader(strings.NewReader(in)).Read(); err == nil || errors.Is(err, io.EOF) {
+				t.Errorf("Read() = %q, %v; want an error", body, err)
+			}
+		})
+	}
+}
+
+func TestReadTooLong(t *testing.T) {
+	in := "Content-Length: 9223372036854775807\r\n\r\n{}"
+	if _, err := NewReader(strings.NewReader(in)).Read(); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Read() failed with %v, want %v", err, ErrTooLong)
+	}
+}
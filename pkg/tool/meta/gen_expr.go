@@ -16,6 +16,10 @@ const (
 
 	visitorMethod = "func (e *%s) Accept(v Visitor) interface{} {\n\treturn v.Visit%s(e)\n}\n\n"
 
+	kindsPreamble = "// Kinds makes an empty node of each type, by name.\nvar Kinds = map[string]func() Type{\n"
+	kindsEntry    = "\t%q: func() Type { return &%s{} },\n"
+	kindsSuffix   = "}\n"
+
 	importPrefix = "import "
 )
 
@@ -48,6 +52,7 @@ func GenExpr(out io.Writer, i *Info) {
 		writeType(out, typ)
 		writeVisitorMethod(out, typ)
 	}
+	writeKinds(out, i.Types)
 }
 
 func writeHeader(out io.Writer, packagename string) {
@@ -88,6 +93,15 @@ func writeVisitorMethod(out io.Writer, typ Typ) {
 	fmt.Fprintf(out, visitorMethod, typ.name, typ.name)
 }
 
+// writeKinds writes a table for constructing nodes by name, as decoders need.
+func writeKinds(out io.Writer, types []Typ) {
+	out.Write([]byte(kindsPreamble))
+	for _, t := range types {
+		fmt.Fprintf(out, kindsEntry, t.name, t.name)
+	}
+	out.Write([]byte(kindsSuffix))
+}
+
 func ParseTypes(info *Info, in string) error {
 	// Sorry about this.
 
@@ -49,6 +49,10 @@ func (e *MyExpr) Accept(v Visitor) interface{} {
 	return v.VisitMyExpr(e)
 }
 
+// Kinds makes an empty node of each type, by name.
+var Kinds = map[string]func() Type{
+	"MyExpr": func() Type { return &MyExpr{} },
+}
 `
 
 	var buf bytes.Buffer